@@ -0,0 +1,196 @@
+package main 
+
+import (
+	"bytes" 
+	"crypto/sha256" 
+	"encoding/json" 
+	"fmt" 
+	"io/ioutil" 
+	"net/http" 
+	"os" 
+
+	"github.com/spf13/cobra" 
+	"github.com/irononet/nemos/core" 
+	"github.com/irononet/nemos/fs" 
+	"github.com/irononet/nemos/node" 
+	"github.com/irononet/nemos/wallet"
+)
+
+const flagNode = "node" 
+const flagFile = "file" 
+const flagHash = "hash" 
+const flagFrom = "from" 
+const flagMemo = "memo" 
+
+const defaultNodeUrl = "http://127.0.0.1:8080" 
+
+func anchorCmd() *cobra.Command{
+	var anchorCmd = &cobra.Command{
+		Use: "anchor", 
+		Short: "Timestamps documents hashes on chain (submit, lookup).", 
+		PreRunE: func(cmd *cobra.Command, args []string) error{
+			return incorrectUsageErr() 
+		}, 
+		Run: func(cmd *cobra.Command, args []string){
+
+		},
+	}
+
+	anchorCmd.AddCommand(anchorSubmitCmd()) 
+	anchorCmd.AddCommand(anchorLookupCmd()) 
+
+	return anchorCmd
+}
+
+func anchorSubmitCmd() *cobra.Command{
+	var cmd = &cobra.Command{
+		Use: "submit", 
+		Short: "signs an anchoring tx of the file hash and submits it to a node.", 
+		Run: func(cmd *cobra.Command, args []string){
+			nodeUrl, _ := cmd.Flags().GetString(flagNode) 
+			from := core.NewAccount(getStringFlag(cmd, flagFrom)) 
+			memo, _ := cmd.Flags().GetString(flagMemo) 
+
+			hash, err := getAnchorHashFromCmd(cmd) 
+			exitOnErr(err) 
+
+			account := node.AccountRes{} 
+			err = getNodeJson(fmt.Sprintf("%s/account?account=%s", nodeUrl, from.Hex()), &account) 
+			exitOnErr(err) 
+
+			fees := node.FeesEstimateRes{} 
+			err = getNodeJson(fmt.Sprintf("%s/fees/estimate", nodeUrl), &fees) 
+			exitOnErr(err) 
+
+			tx := core.NewAnchorTx(from, hash, memo, account.Nonce+1) 
+			tx.GasPrice = 0 
+			tx.MaxFee = fees.MaxFee 
+			tx.MaxTip = fees.MaxTip 
+
+			password := getPassPhrase("please enter the password to decrypt the sender account:", false) 
+			signedTx, err := wallet.SignWithKeystoreAccount(tx, from, password, wallet.GetKeystoreDirPath(getDataDirFromCmd(cmd))) 
+			exitOnErr(err) 
+
+			err = postNodeJson(fmt.Sprintf("%s/tx/submit", nodeUrl), signedTx, &node.TxAddress{}) 
+			exitOnErr(err) 
+
+			txHash, _ := signedTx.Hash() 
+			fmt.Printf("anchoring %s in tx %s\n", hash.Hex(), txHash.Hex()) 
+		},
+	}
+
+	addDefaultRequiredFlags(cmd) 
+	addAnchorHashFlags(cmd) 
+	cmd.Flags().String(flagFrom, "", "account paying for the anchoring tx, from the data dir keystore") 
+	cmd.MarkFlagRequired(flagFrom) 
+	cmd.Flags().String(flagMemo, "", fmt.Sprintf("optional memo stored with the hash (at most %d bytes)", core.MaxAnchorMemoSize)) 
+
+	return cmd 
+}
+
+func anchorLookupCmd() *cobra.Command{
+	var cmd = &cobra.Command{
+		Use: "lookup", 
+		Short: "prints the tx, block and time a file hash was anchored at.", 
+		Run: func(cmd *cobra.Command, args []string){
+			nodeUrl, _ := cmd.Flags().GetString(flagNode) 
+
+			hash, err := getAnchorHashFromCmd(cmd) 
+			exitOnErr(err) 
+
+			anchor := core.Anchor{} 
+			err = getNodeJson(fmt.Sprintf("%s/anchor/%s", nodeUrl, hash.Hex()), &anchor) 
+			exitOnErr(err) 
+
+			fmt.Printf("hash: %s\n", anchor.Hash.Hex()) 
+			fmt.Printf("memo: %s\n", anchor.Memo) 
+			fmt.Printf("from: %s\n", anchor.From.Hex()) 
+			fmt.Printf("tx: %s\n", anchor.TxHash.Hex()) 
+			fmt.Printf("block: %d %s\n", anchor.BlockNumber, anchor.BlockHash.Hex()) 
+			fmt.Printf("time: %d\n", anchor.Time) 
+		},
+	}
+
+	addAnchorHashFlags(cmd) 
+
+	return cmd 
+}
+
+func addAnchorHashFlags(cmd *cobra.Command){
+	cmd.Flags().String(flagNode, defaultNodeUrl, "URL of the node HTTP API") 
+	cmd.Flags().String(flagFile, "", "path to the document, its sha256 hash is anchored") 
+	cmd.Flags().String(flagHash, "", "hex sha256 hash of the document, instead of --file") 
+}
+
+func getAnchorHashFromCmd(cmd *cobra.Command) (core.Hash, error){
+	file, _ := cmd.Flags().GetString(flagFile) 
+	hashHex, _ := cmd.Flags().GetString(flagHash) 
+
+	if file != ""{
+		content, err := ioutil.ReadFile(fs.ExpandPath(file)) 
+		if err != nil{
+			return core.Hash{}, err 
+		}
+		return sha256.Sum256(content), nil 
+	}
+
+	if len(hashHex) != 2*len(core.Hash{}){
+		return core.Hash{}, fmt.Errorf("either --%s or a %d characters hex --%s is required", flagFile, 2*len(core.Hash{}), flagHash)
+	}
+
+	hash := core.Hash{} 
+	err := hash.UnmarshalText([]byte(hashHex)) 
+	return hash, err 
+}
+
+func getStringFlag(cmd *cobra.Command, name string) string{
+	value, _ := cmd.Flags().GetString(name) 
+	return value 
+}
+
+func getNodeJson(url string, res interface{}) error{
+	httpRes, err := http.Get(url) 
+	if err != nil{
+		return err 
+	}
+	return readNodeRes(httpRes, res)
+}
+
+func postNodeJson(url string, req interface{}, res interface{}) error{
+	reqJson, err := json.Marshal(req) 
+	if err != nil{
+		return err 
+	}
+
+	httpRes, err := http.Post(url, "application/json", bytes.NewReader(reqJson)) 
+	if err != nil{
+		return err 
+	}
+	return readNodeRes(httpRes, res)
+}
+
+func readNodeRes(httpRes *http.Response, res interface{}) error{
+	defer httpRes.Body.Close() 
+
+	body, err := ioutil.ReadAll(httpRes.Body) 
+	if err != nil{
+		return err 
+	}
+
+	if httpRes.StatusCode != http.StatusOK{
+		errRes := node.ErrRes{} 
+		if json.Unmarshal(body, &errRes) == nil && errRes.Error != ""{
+			return fmt.Errorf("%s (%s)", errRes.Error, errRes.Code)
+		}
+		return fmt.Errorf("node responded %s", httpRes.Status)
+	}
+
+	return json.Unmarshal(body, res)
+}
+
+func exitOnErr(err error){
+	if err != nil{
+		fmt.Fprintln(os.Stderr, err) 
+		os.Exit(1) 
+	}
+}
@@ -0,0 +1,60 @@
+package main 
+
+import (
+	"fmt" 
+	"os" 
+
+	"github.com/spf13/cobra" 
+)
+
+func configCmd() *cobra.Command{
+	var configCmd = &cobra.Command{
+		Use: "config", 
+		Short: "Inspects the nemos run configuration (dump).", 
+		PreRunE: func(cmd *cobra.Command, args []string) error{
+			return incorrectUsageErr() 
+		}, 
+		Run: func(cmd *cobra.Command, args []string){
+
+		},
+	}
+
+	configCmd.AddCommand(configDumpCmd()) 
+
+	return configCmd
+}
+
+func configDumpCmd() *cobra.Command{
+	var cmd = &cobra.Command{
+		Use: "dump", 
+		Short: "prints the effective configuration, the defaults overridden by the config file and the env vars.", 
+		Run: func(cmd *cobra.Command, args []string){
+			format, _ := cmd.Flags().GetString(flagFormat) 
+
+			cfg, err := loadConfigFromCmd(cmd) 
+			if err != nil{
+				fmt.Fprintln(os.Stderr, err) 
+				os.Exit(1) 
+			}
+
+			err = cfg.Validate() 
+			if err != nil{
+				fmt.Fprintln(os.Stderr, fmt.Errorf("invalid configuration. %s", err.Error())) 
+				os.Exit(1) 
+			}
+
+			content, err := cfg.Encode(format) 
+			if err != nil{
+				fmt.Fprintln(os.Stderr, err) 
+				os.Exit(1) 
+			}
+
+			fmt.Print(string(content)) 
+		},
+	}
+
+	cmd.Flags().String(flagConfig, "", "path to a .json, .yaml or .toml config file") 
+	cmd.Flags().String(flagFormat, "toml", "output format: json, yaml or toml") 
+
+	return cmd
+}
@@ -0,0 +1,102 @@
+package main 
+
+import (
+	"fmt" 
+	"os" 
+
+	"github.com/spf13/cobra" 
+	"github.com/irononet/nemos/core" 
+	"github.com/irononet/nemos/node" 
+)
+
+const flagTruncateTo = "truncate-to" 
+const flagMiningDifficulty = "mining-difficulty" 
+
+func dbCmd() *cobra.Command{
+	var dbCmd = &cobra.Command{
+		Use: "db", 
+		Short: "Checks and repairs the node's block database (verify, repair).", 
+		PreRunE: func(cmd *cobra.Command, args []string) error{
+			return incorrectUsageErr() 
+		}, 
+		Run: func(cmd *cobra.Command, args []string){
+
+		},
+	}
+
+	dbCmd.AddCommand(dbVerifyCmd()) 
+	dbCmd.AddCommand(dbRepairCmd()) 
+
+	return dbCmd
+}
+
+func dbVerifyCmd() *cobra.Command{
+	var cmd = &cobra.Command{
+		Use: "verify", 
+		Short: "replays the whole chain checking hashes, parent links, heights, seals and signatures, and reports the first bad block.", 
+		Run: func(cmd *cobra.Command, args []string){
+			difficulty, _ := cmd.Flags().GetUint(flagMiningDifficulty) 
+
+			report, err := core.VerifyDB(getDataDirFromCmd(cmd), difficulty) 
+			if err != nil{
+				fmt.Fprintln(os.Stderr, err) 
+				os.Exit(1) 
+			}
+
+			printDBReport(report) 
+
+			if report.BadBlock != nil{
+				if report.Blocks > 0{
+					fmt.Printf("repair the db keeping the valid blocks with: nemos db repair --%s %d\n", flagTruncateTo, report.LatestNumber) 
+				}
+				os.Exit(1) 
+			}
+		},
+	}
+
+	addDefaultRequiredFlags(cmd) 
+	cmd.Flags().Uint(flagMiningDifficulty, node.DefaultMiningDifficulty, "proof of work difficulty the chain was mined with") 
+
+	return cmd
+}
+
+func dbRepairCmd() *cobra.Command{
+	var cmd = &cobra.Command{
+		Use: "repair", 
+		Short: "cuts the chain back to a verified block, keeping a backup of the db, and rebuilds the indexes. Stop the node first.", 
+		Run: func(cmd *cobra.Command, args []string){
+			difficulty, _ := cmd.Flags().GetUint(flagMiningDifficulty) 
+			truncateTo, _ := cmd.Flags().GetUint64(flagTruncateTo) 
+
+			report, backupPath, err := core.RepairDB(getDataDirFromCmd(cmd), difficulty, truncateTo) 
+			if backupPath != ""{
+				fmt.Printf("original db backed up to %s\n", backupPath) 
+			}
+			if err != nil{
+				fmt.Fprintln(os.Stderr, err) 
+				os.Exit(1) 
+			}
+
+			fmt.Printf("chain truncated to block '%d'\n", report.LatestNumber) 
+			printDBReport(report) 
+		},
+	}
+
+	addDefaultRequiredFlags(cmd) 
+	cmd.Flags().Uint64(flagTruncateTo, 0, "height of the last block to keep") 
+	cmd.MarkFlagRequired(flagTruncateTo) 
+	cmd.Flags().Uint(flagMiningDifficulty, node.DefaultMiningDifficulty, "proof of work difficulty the chain was mined with") 
+
+	return cmd
+}
+
+func printDBReport(report core.DBReport){
+	fmt.Printf("valid blocks: %d\n", report.Blocks) 
+	if report.Blocks > 0{
+		fmt.Printf("last valid block: %d %s\n", report.LatestNumber, report.LatestHash.Hex()) 
+	}
+
+	if report.BadBlock != nil{
+		fmt.Printf("bad block: %d at line %d (byte %d). %s\n", report.BadBlock.Number, report.BadBlock.Line, report.BadBlock.Offset, report.BadBlock.Err.Error()) 
+	}
+}
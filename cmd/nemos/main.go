@@ -18,6 +18,21 @@ const flagPort = "port"
 const flagBootstrapAcc = "bootstrap-account" 
 const flagBootstrapIp = "bootstrap-ip" 
 const flagBootstrapPort = "bootstrap-port" 
+const flagBootstrap = "bootstrap" 
+const flagPeersFile = "peers-file" 
+const flagLight = "light" 
+const flagBlockTimeDrift = "block-time-drift" 
+const flagSigner = "signer" 
+const flagDev = "dev" 
+const flagMine = "mine" 
+const flagMineEmptyBlocks = "mine-empty-blocks" 
+const flagMineMinTxs = "mine-min-txs" 
+const flagMaxBlockSize = "max-block-size" 
+const flagMiningInterval = "mining-interval" 
+const flagConfig = "config" 
+const flagFormat = "format" 
+const flagLogLevel = "log-level" 
+const flagLogFormat = "log-format" 
 
 func main(){
 	var nemosCmd = &cobra.Command{
@@ -32,6 +47,9 @@ func main(){
 	nemosCmd.AddCommand(balancesCmd()) 
 	nemosCmd.AddCommand(walletCmd()) 
 	nemosCmd.AddCommand(runCmd()) 
+	nemosCmd.AddCommand(anchorCmd()) 
+	nemosCmd.AddCommand(configCmd()) 
+	nemosCmd.AddCommand(dbCmd()) 
 
 	err := nemosCmd.Execute() 
 	if err != nil{
@@ -3,11 +3,17 @@ package main
 import (
 	"context" 
 	"fmt" 
+	"log/slog" 
 	"os" 
+	"os/signal" 
+	"syscall" 
+	"time" 
 
 	"github.com/spf13/cobra" 
 	"github.com/irononet/nemos/core" 
+	"github.com/irononet/nemos/fs" 
 	"github.com/irononet/nemos/node"
+	"github.com/irononet/nemos/wallet"
 )
 
 func runCmd() *cobra.Command{
@@ -15,33 +21,7 @@ func runCmd() *cobra.Command{
 		Use: "run", 
 		Short: "launches the nemos node and its HTTP API.", 
 		Run: func(cmd *cobra.Command, args []string){
-			miner, _ := cmd.Flags().GetString(flagMiner) 
-			sslEmail, _ := cmd.Flags().GetString(flagSSLEmail) 
-			isSSLDisabled, _ := cmd.Flags().GetBool(flagDisableSSL) 
-			ip, _ := cmd.Flags().GetString(flagIP) 
-			port, _ := cmd.Flags().GetUint64(flagPort) 
-			bootstrapIp, _ := cmd.Flags().GetString(flagBootstrapIp) 
-			bootstrapPort, _ := cmd.Flags().GetUint64(flagBootstrapPort) 
-			bootstrapAcc, _ := cmd.Flags().GetString(flagBootstrapAcc) 
-
-			fmt.Println("launching the nemos node and its HTTP API...") 
-
-			bootstrap := node.NewPeerNode(
-				bootstrapIp, 
-				bootstrapPort, 
-				true, 
-				core.NewAccount(bootstrapAcc), 
-				false, 
-				"", 
-			)
-
-			if !isSSLDisabled{
-				port = node.HttpSSLPort
-			}
-
-			version := fmt.Sprintf("%s.%s.%s-alpha %s %s", MAJOR, MINOR, FIX, shortGitCommit(GitCommit), VERBAL) 
-			n := node.New(getDataDirFromCmd(cmd), ip, port, core.NewAccount(miner), bootstrap, version, node.DefaultMiningDifficulty) 
-			err := n.Run(context.Background(), isSSLDisabled, sslEmail) 
+			err := runNode(cmd) 
 			if err != nil{
 				fmt.Println(err) 
 				os.Exit(1) 
@@ -49,15 +29,222 @@ func runCmd() *cobra.Command{
 		},
 	}
 
-	addDefaultRequiredFlags(runCmd) 
-	runCmd.Flags().Bool(flagDisableSSL, false, "should the HTTP API SSL certificate be disabled? (default false)") 
-	runCmd.Flags().String(flagSSLEmail, "", "your node's HTTP SSL certificate email") 
-	runCmd.Flags().String(flagMiner, node.DefaultMiner, "your node's miner account to receive the block rewards") 
-	runCmd.Flags().String(flagIP, node.DefaultIP, "your node's public IP to communication with other peers") 
-	runCmd.Flags().Uint64(flagPort, node.HttpSSLPort, "your node's public HTTP port for communication with other peers (configuragble if SSL is disabled)") 
-	runCmd.Flags().String(flagBootstrapIp, node.DefaultBootstrapIp, "default bootstrap nemos server to interconnect peers") 
-	runCmd.Flags().Uint64(flagBootstrapPort, node.HttpSSLPort, "default bootstrap nemos server port to interconnect peers") 
-	runCmd.Flags().String(flagBootstrapAcc, node.DefaultBootstrapAcc, "default bootstrap nemos genesis account with 1M NEM tokens") 
+	defaults := node.DefaultConfig() 
+
+	// Not required, it can be set in the config file and --dev runs on a temporary data dir
+	runCmd.Flags().String(flagDataDir, "", "absolute path to your node's data dir where the DB will be/is stored") 
+	runCmd.Flags().String(flagConfig, "", "path to a .json, .yaml or .toml config file, overridden by NEMOS_<SECTION>_<KEY> env vars and the flags") 
+	runCmd.Flags().Bool(flagDev, false, "run a throw-away development chain: temporary data dir, prefunded dev account, no peers, no SSL, blocks mined as soon as a tx arrives") 
+	runCmd.Flags().Bool(flagDisableSSL, defaults.API.DisableSSL, "should the HTTP API SSL certificate be disabled? (default false)") 
+	runCmd.Flags().String(flagSSLEmail, defaults.API.SSLEmail, "your node's HTTP SSL certificate email") 
+	runCmd.Flags().String(flagMiner, defaults.Mining.Miner, "your node's miner account to receive the block rewards") 
+	runCmd.Flags().String(flagIP, defaults.Network.IP, "your node's public IP to communication with other peers") 
+	runCmd.Flags().Uint64(flagPort, defaults.Network.Port, "your node's public HTTP port for communication with other peers (configuragble if SSL is disabled)") 
+	runCmd.Flags().String(flagBootstrapIp, defaults.Peers.BootstrapIP, "default bootstrap nemos server to interconnect peers") 
+	runCmd.Flags().Uint64(flagBootstrapPort, defaults.Peers.BootstrapPort, "default bootstrap nemos server port to interconnect peers") 
+	runCmd.Flags().String(flagBootstrapAcc, defaults.Peers.BootstrapAccount, "default bootstrap nemos genesis account with 1M NEM tokens") 
+	runCmd.Flags().StringArray(flagBootstrap, []string{}, "additional bootstrap peer as 'ip:port' (repeatable)") 
+	runCmd.Flags().String(flagPeersFile, defaults.Peers.PeersFile, "path to a file listing bootstrap peers, one 'ip:port' per line") 
+	runCmd.Flags().Uint64(flagBlockTimeDrift, defaults.Network.BlockTimeDrift, "how many seconds ahead of the node clock a block can be stamped") 
+	runCmd.Flags().Bool(flagLight, defaults.Network.Light, "run a light client following the block headers only, balances and txs are proven by full peers") 
+	runCmd.Flags().Bool(flagMine, defaults.Mining.Enabled, "mine blocks, can be toggled at runtime through /miner/start and /miner/stop") 
+	runCmd.Flags().Bool(flagMineEmptyBlocks, defaults.Mining.AllowEmptyBlocks, "mine a block every mining interval even without pending txs") 
+	runCmd.Flags().Int(flagMineMinTxs, defaults.Mining.MinTxs, "pending txs required to mine a block") 
+	runCmd.Flags().Int(flagMaxBlockSize, defaults.Mining.MaxBlockSize, "encoded size limit of the block txs in bytes, 0 for no limit besides the block gas limit") 
+	runCmd.Flags().Duration(flagMiningInterval, time.Duration(defaults.Mining.Interval), "how often the node tries to mine a block") 
+	runCmd.Flags().String(flagLogLevel, defaults.Logging.Level, "minimum level of the logged records: debug, info, warn or error") 
+	runCmd.Flags().String(flagLogFormat, defaults.Logging.Format, "format of the logs written to stderr: text or json") 
+	runCmd.Flags().Bool(flagSigner, defaults.Mining.Signer, "seal proof of authority blocks as a validator with the miner account key (prompts for its keystore password)") 
 
 	return runCmd
-}
\ No newline at end of file
+}
+
+// runNode runs the node until it's stopped, returning instead of exiting so the deferred cleanups run
+func runNode(cmd *cobra.Command) error{
+	isDev, _ := cmd.Flags().GetBool(flagDev) 
+
+	cfg, err := loadConfigFromCmd(cmd) 
+	if err != nil{
+		return err 
+	}
+
+	miningPolicy := cfg.MiningPolicy() 
+
+	if isDev{
+		devDataDir, err := os.MkdirTemp("", "nemos-dev-") 
+		if err != nil{
+			return err 
+		}
+		defer os.RemoveAll(devDataDir) 
+
+		err = node.InitDevDataDir(devDataDir) 
+		if err != nil{
+			return err 
+		}
+
+		cfg.Storage.DataDir = devDataDir 
+		cfg.Mining.Miner = node.DevAccount().Hex() 
+		cfg.Mining.Difficulty = node.DevMiningDifficulty 
+		cfg.Mining.Interval = node.Duration(node.DevMiningPolicy.Interval) 
+		cfg.API.DisableSSL = true 
+		cfg.Peers.BootstrapIP = "" 
+		cfg.Peers.Bootstraps = nil 
+		cfg.Peers.PeersFile = "" 
+		if !cmd.Flags().Changed(flagPort){
+			cfg.Network.Port = node.DevPort 
+		}
+		miningPolicy = node.DevMiningPolicy 
+
+		fmt.Println("development mode, the chain is discarded on exit") 
+		fmt.Printf(" - data dir: %s\n", cfg.Storage.DataDir) 
+		fmt.Printf(" - dev account: %s\n", cfg.Mining.Miner) 
+		fmt.Printf(" - dev private key: %s\n", node.DevPrivateKey) 
+		fmt.Printf(" - dev keystore password: %s\n", node.DevAccountPassword) 
+	}
+
+	err = cfg.Validate() 
+	if err != nil{
+		return fmt.Errorf("invalid configuration. %s", err.Error()) 
+	}
+
+	logger, err := node.NewLogger(os.Stderr, cfg.Logging) 
+	if err != nil{
+		return err 
+	}
+	slog.SetDefault(logger) 
+
+	fmt.Println("launching the nemos node and its HTTP API...") 
+
+	bootstraps := make([]node.PeerNode, 0) 
+	if cfg.Peers.BootstrapIP != ""{
+		bootstraps = append(bootstraps, node.NewPeerNode(
+			cfg.Peers.BootstrapIP, 
+			cfg.Peers.BootstrapPort, 
+			true, 
+			core.NewAccount(cfg.Peers.BootstrapAccount), 
+			false, 
+			"", 
+		))
+	}
+
+	for _, addr := range cfg.Peers.Bootstraps{
+		peer, err := node.ParsePeerAddress(addr) 
+		if err != nil{
+			return err 
+		}
+		bootstraps = append(bootstraps, peer) 
+	}
+
+	if cfg.Peers.PeersFile != ""{
+		peers, err := node.LoadPeersFile(fs.ExpandPath(cfg.Peers.PeersFile)) 
+		if err != nil{
+			return err 
+		}
+		bootstraps = append(bootstraps, peers...) 
+	}
+
+	version := fmt.Sprintf("%s.%s.%s-alpha %s %s", MAJOR, MINOR, FIX, shortGitCommit(GitCommit), VERBAL) 
+	n := node.New(cfg.Storage.DataDir, cfg.Network.IP, cfg.Network.Port, core.NewAccount(cfg.Mining.Miner), bootstraps, version, cfg.Mining.Difficulty) 
+	n.ChangeMaxBlockTimeDrift(cfg.Network.BlockTimeDrift) 
+	n.ChangeMiningPolicy(miningPolicy)
+	if cfg.Network.Light{
+		n.EnableLightMode() 
+	}
+
+	if cfg.Mining.Signer{
+		password := getPassPhrase("please enter the password to decrypt the miner account:", false) 
+		key, err := wallet.DecryptPrivateKey(core.NewAccount(cfg.Mining.Miner), password, wallet.GetKeystoreDirPath(cfg.Storage.DataDir)) 
+		if err != nil{
+			return err 
+		}
+
+		err = n.AuthorizeSigner(key) 
+		if err != nil{
+			return err 
+		}
+	}
+
+	// The node stops serving, syncing and mining before closing its database
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM) 
+	defer stop() 
+
+	return n.Run(ctx, cfg.API.DisableSSL, cfg.API.SSLEmail) 
+}
+
+// loadConfigFromCmd loads the --config file and env vars, then applies the flags set explicitly
+func loadConfigFromCmd(cmd *cobra.Command) (node.Config, error){
+	path, _ := cmd.Flags().GetString(flagConfig) 
+
+	cfg, err := node.LoadConfig(fs.ExpandPath(path)) 
+	if err != nil{
+		return node.Config{}, err 
+	}
+
+	flags := cmd.Flags() 
+	if flags.Changed(flagDataDir){
+		cfg.Storage.DataDir = getDataDirFromCmd(cmd) 
+	}
+	if flags.Changed(flagDisableSSL){
+		cfg.API.DisableSSL, _ = flags.GetBool(flagDisableSSL) 
+	}
+	if flags.Changed(flagSSLEmail){
+		cfg.API.SSLEmail, _ = flags.GetString(flagSSLEmail) 
+	}
+	if flags.Changed(flagMiner){
+		cfg.Mining.Miner, _ = flags.GetString(flagMiner) 
+	}
+	if flags.Changed(flagIP){
+		cfg.Network.IP, _ = flags.GetString(flagIP) 
+	}
+	if flags.Changed(flagPort){
+		cfg.Network.Port, _ = flags.GetUint64(flagPort) 
+	}
+	if flags.Changed(flagBootstrapIp){
+		cfg.Peers.BootstrapIP, _ = flags.GetString(flagBootstrapIp) 
+	}
+	if flags.Changed(flagBootstrapPort){
+		cfg.Peers.BootstrapPort, _ = flags.GetUint64(flagBootstrapPort) 
+	}
+	if flags.Changed(flagBootstrapAcc){
+		cfg.Peers.BootstrapAccount, _ = flags.GetString(flagBootstrapAcc) 
+	}
+	if flags.Changed(flagBootstrap){
+		cfg.Peers.Bootstraps, _ = flags.GetStringArray(flagBootstrap) 
+	}
+	if flags.Changed(flagPeersFile){
+		cfg.Peers.PeersFile, _ = flags.GetString(flagPeersFile) 
+	}
+	if flags.Changed(flagBlockTimeDrift){
+		cfg.Network.BlockTimeDrift, _ = flags.GetUint64(flagBlockTimeDrift) 
+	}
+	if flags.Changed(flagLight){
+		cfg.Network.Light, _ = flags.GetBool(flagLight) 
+	}
+	if flags.Changed(flagMine){
+		cfg.Mining.Enabled, _ = flags.GetBool(flagMine) 
+	}
+	if flags.Changed(flagMineEmptyBlocks){
+		cfg.Mining.AllowEmptyBlocks, _ = flags.GetBool(flagMineEmptyBlocks) 
+	}
+	if flags.Changed(flagMineMinTxs){
+		cfg.Mining.MinTxs, _ = flags.GetInt(flagMineMinTxs) 
+	}
+	if flags.Changed(flagMaxBlockSize){
+		cfg.Mining.MaxBlockSize, _ = flags.GetInt(flagMaxBlockSize) 
+	}
+	if flags.Changed(flagMiningInterval){
+		interval, _ := flags.GetDuration(flagMiningInterval) 
+		cfg.Mining.Interval = node.Duration(interval) 
+	}
+	if flags.Changed(flagLogLevel){
+		cfg.Logging.Level, _ = flags.GetString(flagLogLevel) 
+	}
+	if flags.Changed(flagLogFormat){
+		cfg.Logging.Format, _ = flags.GetString(flagLogFormat) 
+	}
+	if flags.Changed(flagSigner){
+		cfg.Mining.Signer, _ = flags.GetBool(flagSigner) 
+	}
+
+	return cfg, nil 
+}
@@ -0,0 +1,109 @@
+package core 
+
+import (
+	"fmt" 
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const TxTypeAnchor = TxType("anchor")
+
+// An anchor stores a document hash with an optional memo, paying 
+// AnchorGasPerByte on top of TxGas for every byte of the memo
+const MaxAnchorMemoSize = 256
+const AnchorGasPerByte = uint(1)
+
+type AnchorPayload struct{
+	Hash Hash `json:"hash"`
+	Memo string `json:"memo,omitempty"`
+}
+
+// Anchor locates the first tx anchoring a hash
+type Anchor struct{
+	Hash Hash `json:"hash"`
+	Memo string `json:"memo,omitempty"`
+	From common.Address `json:"from"`
+	TxHash Hash `json:"tx_hash"`
+	BlockHash Hash `json:"block_hash"`
+	BlockNumber uint64 `json:"block_number"`
+	Time uint64 `json:"time"`
+}
+
+func init(){
+	registerTxKind(TxTypeAnchor, TxKind{
+		Gas: anchorGas, 
+		Validate: validateAnchorTx, 
+		Apply: func(tx Tx, s *State) error{
+			// Anchors only pay fees, they're indexed once their block is stored
+			return nil 
+		}, 
+	})
+}
+
+func NewAnchorTx(from common.Address, hash Hash, memo string, nonce uint) Tx{
+	tx := NewTypedTx(from, from, TxTypeAnchor, AnchorPayload{hash, memo}, 0, nonce) 
+	tx.Gas = anchorGas(tx) 
+
+	return tx 
+}
+
+func anchorGas(tx Tx) uint{
+	payload := AnchorPayload{} 
+	if tx.DecodePayload(&payload) != nil{
+		return TxGas
+	}
+	return TxGas + AnchorGasPerByte*uint(len(payload.Memo))
+}
+
+func validateAnchorTx(tx Tx, s *State) error{
+	payload := AnchorPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	if payload.Hash.IsEmpty(){
+		return fmt.Errorf("%w: anchored hash can't be empty", ErrInvalidPayload)
+	}
+	if len(payload.Memo) > MaxAnchorMemoSize{
+		return fmt.Errorf("%w: anchor memo is %d bytes, limit is %d", ErrInvalidPayload, len(payload.Memo), MaxAnchorMemoSize)
+	}
+
+	return validateNoValue(tx)
+}
+
+// indexAnchors records the hashes anchored in the stored block, keeping the earliest anchor of a hash
+func (s *State) indexAnchors(b Block, blockHash Hash) error{
+	for _, tx := range b.Txs{
+		if tx.Type != TxTypeAnchor{
+			continue
+		}
+
+		payload := AnchorPayload{} 
+		err := tx.DecodePayload(&payload) 
+		if err != nil{
+			return err 
+		}
+
+		if _, exists := s.anchors[payload.Hash]; exists{
+			continue
+		}
+
+		txHash, err := tx.Hash() 
+		if err != nil{
+			return err 
+		}
+
+		s.anchors[payload.Hash] = Anchor{payload.Hash, payload.Memo, tx.From, txHash, blockHash, b.Header.Number, b.Header.Time}
+	}
+
+	return nil 
+}
+
+func (s *State) GetAnchor(hash Hash) (Anchor, error){
+	anchor, ok := s.anchors[hash] 
+	if !ok{
+		return Anchor{}, fmt.Errorf("%w: hash '%s' isn't anchored", ErrNotFound, hash.Hex())
+	}
+	return anchor, nil 
+}
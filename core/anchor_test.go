@@ -0,0 +1,70 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestAnchorTx(t *testing.T){
+	privKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	from := crypto.PubkeyToAddress(privKey.PublicKey) 
+
+	s := newTestState(map[common.Address]uint{from: 1000}) 
+
+	tx := NewAnchorTx(from, Hash{1}, "contract.pdf", 1) 
+	if tx.Gas != TxGas+AnchorGasPerByte*uint(len("contract.pdf")){
+		t.Errorf("expected the memo bytes to be charged, got %d gas", tx.Gas)
+	}
+
+	tests := []struct{
+		name string 
+		tx Tx 
+		err error 
+	}{
+		{"valid", tx, nil}, 
+		{"empty hash", NewAnchorTx(from, Hash{}, "", 1), ErrInvalidPayload}, 
+		{"memo too long", NewAnchorTx(from, Hash{1}, strings.Repeat("a", MaxAnchorMemoSize+1), 1), ErrInvalidPayload}, 
+	}
+
+	for _, test := range tests{
+		err := ValidateTx(signTestTx(t, test.tx, privKey), s) 
+		if test.err == nil && err != nil{
+			t.Errorf("%s: expected no error, got %s", test.name, err) 
+		}
+		if test.err != nil && !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err) 
+		}
+	}
+
+	first := signTestTx(t, tx, privKey) 
+	again := signTestTx(t, NewAnchorTx(from, Hash{1}, "copy", 2), privKey) 
+
+	err = s.indexAnchors(Block{Header: BlockHeader{Number: 3, Time: 30}, Txs: []SignedTx{first}}, Hash{3}) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	err = s.indexAnchors(Block{Header: BlockHeader{Number: 4, Time: 40}, Txs: []SignedTx{again}}, Hash{4}) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	anchor, err := s.GetAnchor(Hash{1}) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	if anchor.BlockNumber != 3 || anchor.BlockHash != (Hash{3}) || anchor.Memo != "contract.pdf"{
+		t.Errorf("expected the earliest anchor to be kept, got %+v", anchor)
+	}
+
+	_, err = s.GetAnchor(Hash{2}) 
+	if !errors.Is(err, ErrNotFound){
+		t.Errorf("expected %s, got %v", ErrNotFound, err)
+	}
+}
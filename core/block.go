@@ -44,6 +44,13 @@ type BlockHeader struct{
 	Nonce uint32 `json:"nonce"`
 	Time uint64 `json:"time"`
 	Miner common.Address `json:"miner"`
+	TxRoot Hash `json:"tx_root"`
+	StateRoot Hash `json:"state_root"`
+	BaseFee uint `json:"base_fee,omitempty"`
+	// Seal of proof of authority blocks, the validator signature of the SealHash
+	Seal []byte `json:"seal,omitempty"`
+	// Validator set sealing the blocks after this one, only set when the block txs change it
+	Validators []common.Address `json:"validators,omitempty"`
 }
 
 type BlockFS struct{
@@ -51,10 +58,57 @@ type BlockFS struct{
 	Value Block `json:"hash"`
 }
 
+type BlockHeaderFS struct{
+	Key Hash `json:"key"`
+	Value BlockHeader `json:"header"`
+}
+
+// IsLegacy reports whether the header predates the tx and state roots. 
+// The hash of a legacy block covers the whole block instead of the header only.
+func (h BlockHeader) IsLegacy() bool{
+	return h.TxRoot.IsEmpty()
+}
+
+// MarshalJSON keeps the legacy header encoding (and therefore legacy block hashes) intact 
+func (h BlockHeader) MarshalJSON() ([]byte, error){
+	if h.IsLegacy(){
+		type LegacyHeader struct{
+			Parent Hash `json:"parent"`
+			Number uint64 `json:"number"`
+			Nonce uint32 `json:"nonce"`
+			Time uint64 `json:"time"`
+			Miner common.Address `json:"miner"`
+		}
+		return json.Marshal(LegacyHeader{
+			Parent: h.Parent, 
+			Number: h.Number, 
+			Nonce: h.Nonce, 
+			Time: h.Time, 
+			Miner: h.Miner, 
+		})
+	}
+
+	type NemosHeader BlockHeader 
+	return json.Marshal(NemosHeader(h))
+}
+
+// Hash of the header alone. Only meaningful for non legacy headers, 
+// see Block.Hash
+func (h BlockHeader) Hash() (Hash, error){
+	headerJson, err := json.Marshal(h) 
+	if err != nil{
+		return Hash{}, err 
+	}
+	return sha256.Sum256(headerJson), nil 
+}
+
 func NewBlock(parent Hash, 
 		number uint64, nonce uint32, 
 		time uint64, miner common.Address, 
 		txs []SignedTx) Block{
+			// Encoding signed txs can't fail, a failure would surface in applyBlock anyway 
+			txRoot, _ := TxsRoot(txs)
+
 			return Block{
 				BlockHeader{
 					parent, 
@@ -62,12 +116,21 @@ func NewBlock(parent Hash,
 					nonce, 
 					time, 
 					miner, 
+					txRoot, 
+					Hash{}, 
+					0, 
+					nil, 
+					nil, 
 				}, 
 				txs,
 			}
 		}
 
 func (b Block) Hash() (Hash, error){
+	if !b.Header.IsLegacy(){
+		return b.Header.Hash()
+	}
+
 	blockJson, err := json.Marshal(b) 
 	if err != nil{
 		return Hash{}, err 
@@ -75,16 +138,38 @@ func (b Block) Hash() (Hash, error){
 	return sha256.Sum256(blockJson), nil 
 }
 
+// TxsRoot is the merkle root of the block txs committed in the header
+func TxsRoot(txs []SignedTx) (Hash, error){
+	leaves, err := txLeaves(txs) 
+	if err != nil{
+		return Hash{}, err 
+	}
+	return merkleRoot(leaves), nil 
+}
+
+// GasReward sums the tips paid to the miner, the base fee part of the fees is burned
 func (b Block) GasReward() uint{
 	reward := uint(0) 
 
 	for _, tx := range b.Txs{
-		reward += tx.GasCost()
+		if tx.IsCoinbase(){
+			continue
+		}
+		_, tip := tx.Fee(b.Header.BaseFee) 
+		reward += tip
 	}
 
 	return reward
 }
 
+// Coinbase returns the tx paying the miner, always the first one of non legacy blocks
+func (b Block) Coinbase() (SignedTx, bool){
+	if b.Header.IsLegacy() || len(b.Txs) == 0 || !b.Txs[0].IsCoinbase(){
+		return SignedTx{}, false 
+	}
+	return b.Txs[0], true 
+}
+
 func IsBlockHashValid(hash Hash, miningDifficulty uint) bool{
 	zeroesCount := uint(0) 
 
@@ -97,4 +182,4 @@ func IsBlockHashValid(hash Hash, miningDifficulty uint) bool{
 		return false
 	}
 	return zeroesCount == miningDifficulty
-}
\ No newline at end of file
+}
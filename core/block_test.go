@@ -1,7 +1,10 @@
 package core
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"testing"
 
 	//"github.com/ethereum/go-ethereum/common"
@@ -40,5 +43,125 @@ func TestHash(t *testing.T){
 }
 
 func TestBlock(t *testing.T){
-	
-}
\ No newline at end of file
+	txs := []SignedTx{
+		NewSignedTx(NewBaseTx(NewAccount("0x01"), NewAccount("0x02"), 10, 1, ""), []byte{1}), 
+		NewSignedTx(NewBaseTx(NewAccount("0x01"), NewAccount("0x03"), 20, 2, ""), []byte{2}), 
+	}
+
+	block := NewBlock(Hash{}, 0, 1, 1, NewAccount("0x01"), txs) 
+	if block.Header.IsLegacy(){
+		t.Fatalf("expected a block with a tx root")
+	}
+
+	blockHash, err := block.Hash() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	headerHash, err := block.Header.Hash() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	if blockHash != headerHash{
+		t.Errorf("expected the block hash to be the header hash")
+	}
+
+	// Reordering the txs must change the root
+	reordered := NewBlock(Hash{}, 0, 1, 1, NewAccount("0x01"), []SignedTx{txs[1], txs[0]}) 
+	if reordered.Header.TxRoot == block.Header.TxRoot{
+		t.Errorf("expected different tx roots for different tx orders")
+	}
+
+	// Legacy blocks keep hashing the whole block
+	legacy := block 
+	legacy.Header.TxRoot = Hash{} 
+	legacyHash, err := legacy.Hash() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	legacyJson, err := json.Marshal(legacy) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	if legacyHash != sha256.Sum256(legacyJson){
+		t.Errorf("expected legacy block hash to cover the whole block")
+	}
+}
+
+func TestValidateHeaderChain(t *testing.T){
+	headers := make([]BlockHeaderFS, 0) 
+	parent := Hash{} 
+
+	for i := uint64(0); i < 3; i++{
+		block := NewBlock(parent, i, 0, i, NewAccount("0x01"), nil) 
+
+		var hash Hash 
+		for nonce := uint32(0); !IsBlockHashValid(hash, 0); nonce++{
+			block.Header.Nonce = nonce 
+			hash, _ = block.Hash()
+		}
+
+		headers = append(headers, BlockHeaderFS{hash, block.Header}) 
+		parent = hash 
+	}
+
+	err := ValidateHeaderChain(headers, BlockHeaderFS{}, false, NewPoWEngine(0), nil) 
+	if err != nil{
+		t.Fatalf("expected valid header chain, got %s", err) 
+	}
+
+	err = ValidateHeaderChain(headers[1:], headers[0], true, NewPoWEngine(0), nil) 
+	if err != nil{
+		t.Fatalf("expected valid header chain, got %s", err) 
+	}
+
+	err = ValidateHeaderChain(headers[2:], BlockHeaderFS{headers[0].Key, BlockHeader{Number: 1}}, true, NewPoWEngine(0), nil) 
+	if err == nil{
+		t.Errorf("expected an error for a broken parent link") 
+	}
+
+	tampered := append([]BlockHeaderFS(nil), headers...) 
+	tampered[1].Value.Time++ 
+	err = ValidateHeaderChain(tampered, BlockHeaderFS{}, false, NewPoWEngine(0), nil) 
+	if err == nil{
+		t.Errorf("expected an error for a tampered header") 
+	}
+}
+
+func TestValidateHeaderChainForgedLegacyHeader(t *testing.T){
+	rooted := mineTestBlock(NewBlock(Hash{}, 0, 0, 1, NewAccount("0x01"), nil)) 
+	rootedHash, err := rooted.Hash() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	// No work was done for the announced hash, the header alone can't disprove it
+	forged := NewBlock(rootedHash, 1, 0, 2, NewAccount("0x02"), nil) 
+	forged.Header.TxRoot = Hash{} 
+	forgedHeader := BlockHeaderFS{Hash{0x00, 0x00, 0x00, 0x01}, forged.Header} 
+
+	err = ValidateHeaderChain([]BlockHeaderFS{forgedHeader}, BlockHeaderFS{rootedHash, rooted.Header}, true, NewPoWEngine(0), nil) 
+	if !errors.Is(err, ErrLegacyBlock){
+		t.Errorf("expected %s for a legacy header after a rooted one, got %v", ErrLegacyBlock, err) 
+	}
+
+	c, err := NewHeaderChainFromDisk(t.TempDir(), 0) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	defer c.Close() 
+
+	forgedGenesis := forgedHeader 
+	forgedGenesis.Value.Number = 0 
+	forgedGenesis.Value.Parent = Hash{} 
+	err = c.AddHeaders([]BlockHeaderFS{forgedGenesis}) 
+	if !errors.Is(err, ErrLegacyBlock){
+		t.Errorf("expected %s for a legacy header synced by a light node, got %v", ErrLegacyBlock, err) 
+	}
+	if _, ok := c.Latest(); ok{
+		t.Errorf("expected the forged header to be dropped") 
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const ConsensusPoW = "pow"
+const ConsensusPoA = "poa"
+
+// Engine seals the blocks produced by the node and verifies the seal of the received ones.
+// The validators are the authorities of the chain, engines open to anyone ignore them.
+type Engine interface{
+	// Seal completes the header of b so that VerifyHeader accepts it
+	Seal(ctx context.Context, b Block) (Block, error)
+	// VerifyHeader checks the seal of the header hashing to hash on top of its parent
+	VerifyHeader(h BlockHeader, hash Hash, parent BlockHeader, validators []common.Address) error
+	// SealDelay returns how many seconds after its parent the account can seal the block
+	// at the given height, false when it can't seal it
+	SealDelay(account common.Address, number uint64, validators []common.Address) (uint64, bool)
+}
+
+// ConsensusConfig selects the engine of the chain in the genesis file
+type ConsensusConfig struct{
+	Engine string `json:"engine"`
+	Validators []common.Address `json:"validators,omitempty"`
+}
+
+// DefaultConsensusConfig is the proof of work the chain started with
+var DefaultConsensusConfig = ConsensusConfig{Engine: ConsensusPoW}
+
+// NewEngine creates the engine of the config, miningDifficulty only applies to proof of work
+func NewEngine(config ConsensusConfig, miningDifficulty uint) (Engine, error){
+	switch config.Engine{
+	case ConsensusPoW:
+		return NewPoWEngine(miningDifficulty), nil
+	case ConsensusPoA:
+		if len(config.Validators) == 0{
+			return nil, fmt.Errorf("proof of authority requires at least one validator")
+		}
+		return NewPoAEngine(), nil
+	}
+	return nil, fmt.Errorf("unknown consensus engine '%s'", config.Engine)
+}
+
+// PoWEngine seals blocks by searching a nonce giving the block hash enough leading zeroes
+type PoWEngine struct{
+	difficulty uint
+}
+
+func NewPoWEngine(miningDifficulty uint) *PoWEngine{
+	return &PoWEngine{miningDifficulty}
+}
+
+func (e *PoWEngine) Difficulty() uint{
+	return e.difficulty
+}
+
+func (e *PoWEngine) Seal(ctx context.Context, b Block) (Block, error){
+	attempt := 0
+	var hash Hash
+
+	start := time.Now()
+	defer func(){
+		observeMining(attempt, time.Since(start))
+	}()
+
+	for !IsBlockHashValid(hash, e.difficulty){
+		select{
+		case <-ctx.Done():
+			minerLog().Debug("mining cancelled", "attempts", attempt)
+			return Block{}, fmt.Errorf("mining cancelled. %w", ctx.Err())
+		default:
+		}
+
+		attempt++
+		b.Header.Nonce = generateNonce()
+
+		if attempt%1000000 == 0 || attempt == 1{
+			minerLog().Debug("mining", "txs", len(b.Txs), "attempt", attempt)
+		}
+		blockHash, err := b.Hash()
+		if err != nil{
+			return Block{}, fmt.Errorf("couldn't mine block. %s", err.Error())
+		}
+
+		hash = blockHash
+	}
+
+	minerLog().Debug("found nonce", "nonce", b.Header.Nonce, "attempts", attempt)
+
+	return b, nil
+}
+
+func (e *PoWEngine) VerifyHeader(h BlockHeader, hash Hash, parent BlockHeader, validators []common.Address) error{
+	if !IsBlockHashValid(hash, e.difficulty){
+		return fmt.Errorf("%w: invalid block hash %x", ErrInvalidPoW, hash)
+	}
+	return nil
+}
+
+func (e *PoWEngine) SealDelay(account common.Address, number uint64, validators []common.Address) (uint64, bool){
+	return 0, true
+}
+
+func generateNonce() uint32{
+	rand.Seed(time.Now().UTC().UnixNano())
+
+	return rand.Uint32()
+}
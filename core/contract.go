@@ -0,0 +1,180 @@
+package core 
+
+import (
+	"fmt" 
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const TxTypeContractDeploy = TxType("contract_deploy")
+const TxTypeContractCall = TxType("contract_call")
+
+// Deployments pay ContractGasPerByte of code on top of TxGas. 
+// Calls provide a gas limit, all of it is paid whatever the execution uses.
+const MaxContractCodeSize = 1024
+const ContractGasPerByte = uint(1)
+const MaxCallGas = BlockGasLimit
+
+type Contract struct{
+	Address common.Address `json:"address"`
+	Creator common.Address `json:"creator"`
+	Code []byte `json:"code"`
+}
+
+type ContractDeployPayload struct{
+	Code []byte `json:"code"`
+}
+
+type ContractCallPayload struct{
+	Contract common.Address `json:"contract"`
+	Args []Hash `json:"args"`
+}
+
+// CallResult is the outcome of a contract execution
+type CallResult struct{
+	Result Hash `json:"result"`
+	GasUsed uint `json:"gas_used"`
+}
+
+func init(){
+	registerTxKind(TxTypeContractDeploy, TxKind{
+		Gas: contractDeployGas, 
+		Validate: validateContractDeployTx, 
+		Apply: applyContractDeployTx, 
+	})
+	registerTxKind(TxTypeContractCall, TxKind{
+		Gas: func(tx Tx) uint{
+			return tx.Gas
+		}, 
+		Validate: validateContractCallTx, 
+		Apply: applyContractCallTx, 
+	})
+}
+
+// ContractAddress is the address of the contract deployed by the sender tx with the given nonce
+func ContractAddress(creator common.Address, nonce uint) common.Address{
+	return crypto.CreateAddress(creator, uint64(nonce))
+}
+
+func NewContractDeployTx(from common.Address, code []byte, value, nonce uint) Tx{
+	tx := NewTypedTx(from, ContractAddress(from, nonce), TxTypeContractDeploy, ContractDeployPayload{code}, value, nonce) 
+	tx.Gas = contractDeployGas(tx) 
+
+	return tx 
+}
+
+func NewContractCallTx(from, contract common.Address, args []Hash, gasLimit, value, nonce uint) Tx{
+	tx := NewTypedTx(from, contract, TxTypeContractCall, ContractCallPayload{contract, args}, value, nonce) 
+	tx.Gas = gasLimit 
+
+	return tx 
+}
+
+func contractDeployGas(tx Tx) uint{
+	payload := ContractDeployPayload{} 
+	if tx.DecodePayload(&payload) != nil{
+		return TxGas
+	}
+	return TxGas + ContractGasPerByte*uint(len(payload.Code))
+}
+
+func validateContractDeployTx(tx Tx, s *State) error{
+	payload := ContractDeployPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	if len(payload.Code) == 0 || len(payload.Code) > MaxContractCodeSize{
+		return fmt.Errorf("%w: contract code must be 1 to %d bytes, got %d", ErrInvalidPayload, MaxContractCodeSize, len(payload.Code))
+	}
+	if tx.To != ContractAddress(tx.From, tx.Nonce){
+		return fmt.Errorf("%w: contract address must be '%s' not '%s'", ErrInvalidPayload, ContractAddress(tx.From, tx.Nonce).String(), tx.To.String())
+	}
+	if _, exists := s.Contracts[tx.To]; exists{
+		return fmt.Errorf("%w: contract '%s' already exists", ErrInvalidPayload, tx.To.String())
+	}
+
+	return nil 
+}
+
+func applyContractDeployTx(tx Tx, s *State) error{
+	payload := ContractDeployPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	s.Contracts[tx.To] = Contract{tx.To, tx.From, payload.Code}
+	s.Balances[tx.From] -= tx.Value 
+	s.Balances[tx.To] += tx.Value 
+
+	return nil 
+}
+
+func validateContractCallTx(tx Tx, s *State) error{
+	payload := ContractCallPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	if tx.Gas <= TxGas || tx.Gas > MaxCallGas{
+		return fmt.Errorf("%w: contract call gas must be above %d and at most %d", ErrInsufficientGas, TxGas, MaxCallGas)
+	}
+	if payload.Contract != tx.To{
+		return fmt.Errorf("%w: tx must be sent to the called contract '%s'", ErrInvalidPayload, payload.Contract.String())
+	}
+	if _, exists := s.Contracts[tx.To]; !exists{
+		return fmt.Errorf("%w: contract '%s'", ErrNotFound, tx.To.String())
+	}
+
+	return nil 
+}
+
+// applyContractCallTx runs the contract, its storage and balance only change if the execution succeeds. 
+// A reverted or out of gas call is still included, the sender pays its gas limit and keeps the value.
+func applyContractCallTx(tx Tx, s *State) error{
+	payload := ContractCallPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	call := newVMCall(s, tx.To, tx.From, tx.Value, payload.Args, tx.Gas-TxGas) 
+	_, gasUsed, err := call.run(s.Contracts[tx.To].Code) 
+	if err != nil{
+		stateLog().Debug("contract call failed", "contract", tx.To.Hex(), "from", tx.From.Hex(), "gas_used", gasUsed, "err", err) 
+		return nil 
+	}
+
+	s.Balances[tx.From] -= tx.Value 
+	s.Balances[tx.To] += tx.Value 
+	call.commit() 
+
+	return nil 
+}
+
+// CallContract runs the contract on top of the current state without changing it
+func (s *State) CallContract(contract, caller common.Address, args []Hash) (CallResult, error){
+	c, exists := s.Contracts[contract] 
+	if !exists{
+		return CallResult{}, fmt.Errorf("%w: contract '%s'", ErrNotFound, contract.String())
+	}
+
+	result, gasUsed, err := newVMCall(s, contract, caller, 0, args, MaxCallGas).run(c.Code) 
+	if err != nil{
+		return CallResult{}, err 
+	}
+
+	return CallResult{result, gasUsed}, nil 
+}
+
+// ContractStorageAt reads a word of the contract storage
+func (s *State) ContractStorageAt(contract common.Address, key Hash) (Hash, error){
+	if _, exists := s.Contracts[contract]; !exists{
+		return Hash{}, fmt.Errorf("%w: contract '%s'", ErrNotFound, contract.String())
+	}
+	return s.ContractStorage[contract][key], nil 
+}
@@ -0,0 +1,162 @@
+package core 
+
+import (
+	"bufio" 
+	"fmt" 
+	"io" 
+	"os" 
+	"time" 
+)
+
+// DBReport describes the chain replayed from the block db 
+type DBReport struct{
+	Blocks uint64 
+	LatestNumber uint64 
+	LatestHash Hash 
+
+	// The first record failing the verification, nil if all the records are valid 
+	BadBlock *BadBlock 
+}
+
+// BadBlock locates an invalid record of the block db. Number is the expected block 
+// number when the record can't be decoded. 
+type BadBlock struct{
+	Number uint64 
+	Line int 
+	Offset int64 
+	Err error 
+}
+
+// VerifyDB replays the block db over the genesis, checking every block hash, parent link, 
+// number, seal, tx signature and state root, and reports the first invalid record. 
+// The db isn't modified. 
+func VerifyDB(dataDir string, miningDifficulty uint) (DBReport, error){
+	report, _, err := scanDB(dataDir, miningDifficulty, 0, false) 
+	return report, err 
+}
+
+// RepairDB cuts the block db after the block numbered truncateTo once the blocks up to it 
+// are verified. The original db is kept as a backup next to it, then the state is reloaded 
+// to rebuild the indexes. The node must be stopped. 
+func RepairDB(dataDir string, miningDifficulty uint, truncateTo uint64) (DBReport, string, error){
+	report, end, err := scanDB(dataDir, miningDifficulty, truncateTo, true) 
+	if err != nil{
+		return report, "", err 
+	}
+	if report.BadBlock != nil{
+		return report, "", fmt.Errorf("block '%d' is invalid, truncate below it. %w", report.BadBlock.Number, report.BadBlock.Err) 
+	}
+	if report.Blocks == 0 || report.LatestNumber != truncateTo{
+		return report, "", fmt.Errorf("%w: the chain has no block '%d'", ErrNotFound, truncateTo) 
+	}
+
+	dbFilepath := getBlocksDbFilePath(dataDir) 
+	backupPath := fmt.Sprintf("%s.%d.bak", dbFilepath, time.Now().Unix()) 
+	err = copyFile(dbFilepath, backupPath) 
+	if err != nil{
+		return report, "", err 
+	}
+
+	f, err := os.OpenFile(dbFilepath, os.O_RDWR, 0600) 
+	if err != nil{
+		return report, backupPath, err 
+	}
+	defer f.Close() 
+
+	err = f.Truncate(end) 
+	if err != nil{
+		return report, backupPath, err 
+	}
+	err = f.Sync() 
+	if err != nil{
+		return report, backupPath, err 
+	}
+
+	state, err := NewStateFromDisk(dataDir, miningDifficulty) 
+	if err != nil{
+		return report, backupPath, err 
+	}
+
+	return report, backupPath, state.Close() 
+}
+
+// scanDB replays the block db records until the block numbered until, or to the end 
+// of the db without until. It returns the offset right after the last valid record. 
+func scanDB(dataDir string, miningDifficulty uint, until uint64, hasUntil bool) (DBReport, int64, error){
+	state, err := newGenesisState(dataDir, miningDifficulty) 
+	if err != nil{
+		return DBReport{}, 0, err 
+	}
+
+	f, err := os.Open(getBlocksDbFilePath(dataDir)) 
+	if err != nil{
+		return DBReport{}, 0, err 
+	}
+	defer f.Close() 
+
+	report := DBReport{} 
+	reader := bufio.NewReader(f) 
+	filePos := int64(0) 
+	line := 0 
+
+	for !hasUntil || report.Blocks == 0 || report.LatestNumber < until{
+		record, err := reader.ReadBytes('\n') 
+		if err == io.EOF && len(record) == 0{
+			break
+		}
+		line++ 
+
+		if err == io.EOF{
+			report.BadBlock = &BadBlock{state.NextBlockNumber(), line, filePos, fmt.Errorf("partially written record")} 
+			break
+		}
+		if err != nil{
+			return report, filePos, err 
+		}
+
+		blockFsJson := record[:len(record)-1] 
+		if len(blockFsJson) == 0{
+			break
+		}
+
+		err = state.loadBlock(blockFsJson, filePos) 
+		if err != nil{
+			report.BadBlock = &BadBlock{state.NextBlockNumber(), line, filePos, err} 
+			break
+		}
+
+		filePos += int64(len(record)) 
+		report.Blocks++ 
+		report.LatestNumber = state.latestBlock.Header.Number 
+		report.LatestHash = state.latestBlockHash 
+	}
+
+	return report, filePos, nil 
+}
+
+func copyFile(src string, dst string) error{
+	in, err := os.Open(src) 
+	if err != nil{
+		return err 
+	}
+	defer in.Close() 
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600) 
+	if err != nil{
+		return err 
+	}
+
+	_, err = io.Copy(out, in) 
+	if err != nil{
+		out.Close() 
+		return err 
+	}
+
+	err = out.Sync() 
+	if err != nil{
+		out.Close() 
+		return err 
+	}
+
+	return out.Close() 
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"os" 
+	"strings" 
+	"testing" 
+)
+
+// newTestBlockDB mines a chain of empty blocks in a new data dir 
+func newTestBlockDB(t *testing.T, blocks int) string{
+	dataDir := t.TempDir() 
+
+	state, err := NewStateFromDisk(dataDir, 0) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	defer state.Close() 
+
+	miner := NewAccount("0x01") 
+	for i := 0; i < blocks; i++{
+		number := state.NextBlockNumber() 
+		blockTime := uint64(1700000000 + i*10) 
+
+		coinbase := NewCoinbaseTx(miner, number, blockTime, state.BlockRewardAt(number)) 
+		block := NewBlock(state.LatestBlockHash(), number, 0, blockTime, miner, []SignedTx{coinbase}) 
+		block.Header.BaseFee = state.NextBaseFee() 
+
+		block.Header.StateRoot, err = state.StateRootAfter(block) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+
+		_, err = state.AddBlock(block) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+	}
+
+	return dataDir 
+}
+
+func editBlockDB(t *testing.T, dataDir string, edit func(lines []string) []string){
+	content, err := os.ReadFile(getBlocksDbFilePath(dataDir)) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") 
+	lines = edit(lines) 
+
+	err = os.WriteFile(getBlocksDbFilePath(dataDir), []byte(strings.Join(lines, "\n")+"\n"), 0600) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+}
+
+func TestVerifyDB(t *testing.T){
+	dataDir := newTestBlockDB(t, 5) 
+
+	report, err := VerifyDB(dataDir, 0) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	if report.BadBlock != nil{
+		t.Fatalf("expected a valid db, got %v", report.BadBlock.Err) 
+	}
+	if report.Blocks != 5 || report.LatestNumber != 4{
+		t.Fatalf("expected 5 blocks up to '4', got %d up to '%d'", report.Blocks, report.LatestNumber) 
+	}
+
+	tests := []struct{
+		name string 
+		edit func(lines []string) []string 
+		number uint64 
+		line int 
+	}{
+		{"swapped blocks", func(lines []string) []string{
+			lines[2], lines[3] = lines[3], lines[2] 
+			return lines 
+		}, 2, 3}, 
+		{"unreadable record", func(lines []string) []string{
+			lines[3] = "{\"key\":" 
+			return lines 
+		}, 3, 4}, 
+		{"edited block", func(lines []string) []string{
+			lines[1] = strings.Replace(lines[1], "\"time\":1700000010", "\"time\":1700000011", 1) 
+			return lines 
+		}, 1, 2}, 
+	}
+
+	for _, test := range tests{
+		t.Run(test.name, func(t *testing.T){
+			dataDir := newTestBlockDB(t, 5) 
+			editBlockDB(t, dataDir, test.edit) 
+
+			report, err := VerifyDB(dataDir, 0) 
+			if err != nil{
+				t.Fatal(err) 
+			}
+			if report.BadBlock == nil{
+				t.Fatalf("expected an invalid block") 
+			}
+			if report.BadBlock.Number != test.number || report.BadBlock.Line != test.line{
+				t.Errorf("expected block '%d' at line %d, got '%d' at line %d. %v", test.number, test.line, report.BadBlock.Number, report.BadBlock.Line, report.BadBlock.Err) 
+			}
+			if report.Blocks != test.number{
+				t.Errorf("expected %d valid blocks, got %d", test.number, report.Blocks) 
+			}
+		})
+	}
+}
+
+func TestRepairDB(t *testing.T){
+	dataDir := newTestBlockDB(t, 5) 
+	editBlockDB(t, dataDir, func(lines []string) []string{
+		lines[3] = "{\"key\":" 
+		return lines 
+	})
+
+	_, err := NewStateFromDisk(dataDir, 0) 
+	if err == nil{
+		t.Fatalf("expected the corrupted db to fail loading") 
+	}
+
+	_, _, err = RepairDB(dataDir, 0, 3) 
+	if err == nil{
+		t.Fatalf("expected the repair to refuse keeping the invalid block '3'") 
+	}
+
+	report, backupPath, err := RepairDB(dataDir, 0, 2) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	if report.LatestNumber != 2{
+		t.Errorf("expected the chain cut at '2', got '%d'", report.LatestNumber) 
+	}
+
+	backup, err := os.ReadFile(backupPath) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	if strings.Count(string(backup), "\n") != 5{
+		t.Errorf("expected the backup to keep the 5 records") 
+	}
+
+	state, err := NewStateFromDisk(dataDir, 0) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	defer state.Close() 
+
+	if state.LatestBlock().Header.Number != 2 || state.LatestBlockHash() != report.LatestHash{
+		t.Errorf("expected the repaired chain to end at '2'") 
+	}
+	if len(state.HeightCache) != 3{
+		t.Errorf("expected the indexes of 3 blocks, got %d", len(state.HeightCache)) 
+	}
+
+	_, _, err = RepairDB(dataDir, 0, 7) 
+	if err == nil{
+		t.Errorf("expected the repair past the tip to fail") 
+	}
+}
@@ -0,0 +1,71 @@
+package core 
+
+// EmissionSchedule defines the block reward: it starts at InitialReward, halves every 
+// HalvingInterval blocks without going below TailEmission and stops once MaxSupply is reached. 
+// A zero HalvingInterval or MaxSupply disables halving or the supply cap.
+type EmissionSchedule struct{
+	InitialReward uint `json:"initial_reward"`
+	HalvingInterval uint64 `json:"halving_interval"`
+	TailEmission uint `json:"tail_emission"`
+	MaxSupply uint `json:"max_supply"`
+}
+
+type Supply struct{
+	Circulating uint `json:"circulating"`
+	Genesis uint `json:"genesis"`
+	Minted uint `json:"minted"`
+	BurnedFees uint `json:"burned_fees"`
+	MaxSupply uint `json:"max_supply"`
+}
+
+// DefaultEmissionSchedule is used by genesis files predating the schedule: a constant reward forever
+var DefaultEmissionSchedule = EmissionSchedule{InitialReward: BlockReward}
+
+// scheduledReward is the reward of the block at the given height, ignoring the supply cap
+func (e EmissionSchedule) scheduledReward(number uint64) uint{
+	reward := e.InitialReward 
+
+	if e.HalvingInterval > 0{
+		halvings := number / e.HalvingInterval 
+		if halvings >= 64{
+			reward = 0 
+		} else{
+			reward >>= halvings 
+		}
+	}
+
+	if reward < e.TailEmission{
+		reward = e.TailEmission
+	}
+
+	return reward 
+}
+
+// BlockRewardAt returns the reward of the block at the given height on top of the current state
+func (s *State) BlockRewardAt(number uint64) uint{
+	reward := s.emission.scheduledReward(number) 
+
+	if s.emission.MaxSupply == 0{
+		return reward 
+	}
+
+	issued := s.genesisSupply + s.totalMinted 
+	if issued >= s.emission.MaxSupply{
+		return 0 
+	}
+	if issued + reward > s.emission.MaxSupply{
+		return s.emission.MaxSupply - issued
+	}
+
+	return reward 
+}
+
+func (s *State) Supply() Supply{
+	return Supply{
+		Circulating: s.genesisSupply + s.totalMinted - s.totalBurned, 
+		Genesis: s.genesisSupply, 
+		Minted: s.totalMinted, 
+		BurnedFees: s.totalBurned, 
+		MaxSupply: s.emission.MaxSupply, 
+	}
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBlockRewardAt(t *testing.T){
+	s := newTestState(map[common.Address]uint{}) 
+	s.emission = EmissionSchedule{InitialReward: 100, HalvingInterval: 10, TailEmission: 10, MaxSupply: 1000} 
+	s.genesisSupply = 500 
+
+	tests := []struct{
+		number uint64 
+		minted uint 
+		reward uint 
+	}{
+		{0, 0, 100}, 
+		{9, 0, 100}, 
+		{10, 0, 50}, 
+		{25, 0, 25}, 
+		{35, 0, 12}, 
+		{45, 0, 10}, 
+		{1000, 0, 10}, 
+		{0, 450, 50}, 
+		{0, 500, 0}, 
+	}
+
+	for _, test := range tests{
+		s.totalMinted = test.minted 
+
+		reward := s.BlockRewardAt(test.number) 
+		if reward != test.reward{
+			t.Errorf("block %d with %d minted: expected reward %d, got %d", test.number, test.minted, test.reward, reward) 
+		}
+	}
+}
+
+func TestDefaultEmissionSchedule(t *testing.T){
+	s := newTestState(map[common.Address]uint{}) 
+
+	if s.BlockRewardAt(10000000) != BlockReward{
+		t.Errorf("expected the default schedule to keep a constant reward of %d", BlockReward) 
+	}
+}
@@ -0,0 +1,38 @@
+package core 
+
+import "errors"
+
+// Validation errors are wrapped with the details of the failure, 
+// use errors.Is to tell them apart.
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidNonce = errors.New("invalid nonce")
+	ErrInsufficientGas = errors.New("insufficient gas")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrFeeTooLow = errors.New("fee too low")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownTxType = errors.New("unknown tx type")
+	ErrInvalidPayload = errors.New("invalid tx payload")
+	ErrLocked = errors.New("funds locked")
+	ErrContractFailed = errors.New("contract execution failed")
+	ErrOutOfGas = errors.New("out of gas")
+	ErrNotValidator = errors.New("not a validator")
+
+	ErrBadBlockNumber = errors.New("bad block number")
+	ErrBadParent = errors.New("bad parent")
+	ErrInvalidPoW = errors.New("invalid proof of work")
+	ErrBadBlockHash = errors.New("bad block hash")
+	ErrBadTxRoot = errors.New("bad tx root")
+	ErrBadStateRoot = errors.New("bad state root")
+	ErrBadTimestamp = errors.New("bad timestamp")
+	ErrBlockFromFuture = errors.New("block from the future")
+	ErrBadCoinbase = errors.New("bad coinbase")
+	ErrBadBaseFee = errors.New("bad base fee")
+	ErrGasLimitExceeded = errors.New("block gas limit exceeded")
+	ErrBadSeal = errors.New("bad seal")
+	ErrBadValidators = errors.New("bad validators")
+	ErrLegacyBlock = errors.New("legacy block")
+	ErrFinalized = errors.New("below finalized block")
+
+	ErrNotFound = errors.New("not found")
+)
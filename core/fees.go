@@ -0,0 +1,125 @@
+package core 
+
+import (
+	"sort"
+)
+
+// The base fee (per gas) of a block rises when its parent used more than BlockGasTarget 
+// and drops when it used less, by at most 1/BaseFeeChangeDenominator per block. 
+// It's burned, only the tips go to the miner. 
+// The target is a full multi transfer so the largest batch fits without raising the base fee.
+const BlockGasTarget = uint(MaxTxOutputs * TxGasPerOutput)
+const BlockGasLimit = 2 * BlockGasTarget
+const InitialBaseFee = uint(1)
+const MinBaseFee = uint(1)
+const BaseFeeChangeDenominator = uint(8)
+
+type FeeEstimate struct{
+	BaseFee uint `json:"base_fee"`
+	MaxTip uint `json:"max_tip"`
+	MaxFee uint `json:"max_fee"`
+	Gas uint `json:"gas"`
+}
+
+// GasUsed by the block txs, the coinbase doesn't use any
+func (b Block) GasUsed() uint{
+	used := uint(0) 
+	for _, tx := range b.Txs{
+		used += tx.Gas 
+	}
+	return used 
+}
+
+// NextBaseFee is the base fee of the block on top of the current chain tip. 
+// Blocks following a legacy block (which has no base fee) start at InitialBaseFee.
+func (s *State) NextBaseFee() uint{
+	if !s.hasGenesisBlock || s.latestBlock.Header.IsLegacy(){
+		return InitialBaseFee
+	}
+
+	parent := s.latestBlock.Header 
+	used := s.latestBlock.GasUsed() 
+
+	if used == BlockGasTarget{
+		return parent.BaseFee
+	}
+
+	if used > BlockGasTarget{
+		delta := parent.BaseFee * (used - BlockGasTarget) / BlockGasTarget / BaseFeeChangeDenominator 
+		if delta < 1{
+			delta = 1
+		}
+		return parent.BaseFee + delta 
+	}
+
+	delta := parent.BaseFee * (BlockGasTarget - used) / BlockGasTarget / BaseFeeChangeDenominator 
+	if parent.BaseFee < MinBaseFee + delta{
+		return MinBaseFee
+	}
+	return parent.BaseFee - delta
+}
+
+// SuggestedTip is the median tip per gas paid in the latest block, at least 1
+func (s *State) SuggestedTip() uint{
+	tips := make([]uint, 0, len(s.latestBlock.Txs)) 
+	for _, tx := range s.latestBlock.Txs{
+		if tx.IsCoinbase() || tx.Gas == 0{
+			continue
+		}
+		_, tip := tx.Fee(s.latestBlock.Header.BaseFee) 
+		tips = append(tips, tip/tx.Gas)
+	}
+
+	if len(tips) == 0{
+		return 1 
+	}
+
+	sort.Slice(tips, func(i, j int) bool{
+		return tips[i] < tips[j]
+	})
+	if tips[len(tips)/2] < 1{
+		return 1 
+	}
+	return tips[len(tips)/2]
+}
+
+// EstimateFee suggests fees for a transfer landing in one of the next blocks. 
+// The max fee leaves room for the base fee to double.
+func (s *State) EstimateFee() FeeEstimate{
+	baseFee := s.NextBaseFee() 
+	tip := s.SuggestedTip() 
+
+	return FeeEstimate{
+		BaseFee: baseFee, 
+		MaxTip: tip, 
+		MaxFee: 2*baseFee + tip, 
+		Gas: TxGas, 
+	}
+}
+
+// PackTxs selects, in time order, the txs valid on top of the current state 
+// fitting in the next block gas limit
+func (s *State) PackTxs(txs []SignedTx) []SignedTx{
+	txs = append([]SignedTx(nil), txs...) 
+	sort.Slice(txs, func(i, j int) bool{
+		return txs[i].Time < txs[j].Time
+	})
+
+	pending := s.Copy() 
+	baseFee := s.NextBaseFee() 
+	gasUsed := uint(0) 
+
+	packed := make([]SignedTx, 0, len(txs)) 
+	for _, tx := range txs{
+		if gasUsed + tx.Gas > BlockGasLimit{
+			continue
+		}
+		if applyTx(tx, &pending, baseFee, false) != nil{
+			continue
+		}
+		gasUsed += tx.Gas 
+		packed = append(packed, tx) 
+	}
+
+	return packed 
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestTxFee(t *testing.T){
+	from, to := NewAccount("0x01"), NewAccount("0x02") 
+
+	tests := []struct{
+		name string 
+		tx Tx 
+		baseFee uint 
+		burn uint 
+		tip uint 
+	}{
+		{"legacy no base fee", NewBaseTx(from, to, 1, 1, ""), 0, 0, 1}, 
+		{"legacy", NewTx(from, to, 3, 2, 1, 1, ""), 1, 3, 3}, 
+		{"legacy at base fee", NewBaseTx(from, to, 1, 1, ""), 1, 1, 0}, 
+		{"dynamic", NewDynamicFeeTx(from, to, TxGas, 10, 3, 1, 1, ""), 4, 4, 3}, 
+		{"dynamic capped tip", NewDynamicFeeTx(from, to, TxGas, 10, 3, 1, 1, ""), 8, 8, 2}, 
+		{"dynamic max tip", NewDynamicFeeTx(from, to, TxGas, math.MaxUint, math.MaxUint, 5, 1, ""), 1, 1, math.MaxUint-1}, 
+	}
+
+	for _, test := range tests{
+		burn, tip := test.tx.Fee(test.baseFee) 
+		if burn != test.burn || tip != test.tip{
+			t.Errorf("%s: expected burn %d and tip %d, got %d and %d", test.name, test.burn, test.tip, burn, tip)
+		}
+	}
+}
+
+func TestNextBaseFee(t *testing.T){
+	miner := NewAccount("0x01") 
+
+	blockUsing := func(baseFee uint, gas uint) Block{
+		txs := make([]SignedTx, gas) 
+		for i := range txs{
+			txs[i] = NewSignedTx(NewBaseTx(miner, miner, 0, uint(i+1), ""), nil)
+		}
+		b := NewBlock(Hash{}, 1, 0, 1, miner, txs) 
+		b.Header.BaseFee = baseFee 
+		return b 
+	}
+
+	outputs := make([]TxOutput, MaxTxOutputs) 
+	for i := range outputs{
+		outputs[i] = TxOutput{miner, 1}
+	}
+	fullBatch := blockUsing(16, 0) 
+	fullBatch.Txs = []SignedTx{NewSignedTx(NewMultiTransferTx(miner, outputs, 1), nil)} 
+
+	tests := []struct{
+		name string 
+		parent Block 
+		baseFee uint 
+	}{
+		{"legacy parent", Block{Header: BlockHeader{Number: 1}}, InitialBaseFee}, 
+		{"full batch at target", fullBatch, 16}, 
+		{"at target", blockUsing(16, BlockGasTarget), 16}, 
+		{"full", blockUsing(16, BlockGasLimit), 18}, 
+		{"empty", blockUsing(16, 0), 14}, 
+		{"min", blockUsing(MinBaseFee, 0), MinBaseFee}, 
+		{"min increase", blockUsing(1, BlockGasTarget+1), 2}, 
+	}
+
+	for _, test := range tests{
+		s := newTestState(map[common.Address]uint{}) 
+		s.latestBlock = test.parent 
+		s.hasGenesisBlock = true 
+
+		if baseFee := s.NextBaseFee(); baseFee != test.baseFee{
+			t.Errorf("%s: expected base fee %d, got %d", test.name, test.baseFee, baseFee)
+		}
+	}
+}
+
+func TestValidateTxFees(t *testing.T){
+	privKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	from := crypto.PubkeyToAddress(privKey.PublicKey) 
+	to := NewAccount("0x02") 
+
+	s := newTestState(map[common.Address]uint{from: 100}) 
+
+	tests := []struct{
+		name string 
+		tx Tx 
+		baseFee uint 
+		err error 
+	}{
+		{"dynamic", NewDynamicFeeTx(from, to, TxGas, 5, 1, 10, 1, ""), 4, nil}, 
+		{"max fee below base fee", NewDynamicFeeTx(from, to, TxGas, 3, 1, 10, 1, ""), 4, ErrFeeTooLow}, 
+		{"tip above max fee", NewDynamicFeeTx(from, to, TxGas, 5, 6, 10, 1, ""), 4, ErrFeeTooLow}, 
+		{"legacy below base fee", NewBaseTx(from, to, 10, 1, ""), 4, ErrFeeTooLow}, 
+		{"max cost", NewDynamicFeeTx(from, to, TxGas, 20, 1, 90, 1, ""), 4, ErrInsufficientBalance}, 
+		{"max fee overflow", NewDynamicFeeTx(from, to, TxGas, math.MaxUint, math.MaxUint, 5, 1, ""), 4, ErrInvalidPayload}, 
+		{"gas price overflow", NewTx(from, to, TxGas, math.MaxUint, 5, 1, ""), 4, ErrInvalidPayload}, 
+	}
+
+	for _, test := range tests{
+		err := validateTx(signTestTx(t, test.tx, privKey), s, test.baseFee, false) 
+		if test.err == nil && err != nil{
+			t.Errorf("%s: expected no error, got %s", test.name, err) 
+		}
+		if test.err != nil && !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err) 
+		}
+	}
+
+	burnedBefore := s.totalBurned 
+	err = applyTx(signTestTx(t, NewDynamicFeeTx(from, to, TxGas, 5, 1, 10, 1, ""), privKey), s, 4, false) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	if s.Balances[from] != 85 || s.Balances[to] != 10 || s.totalBurned-burnedBefore != 4{
+		t.Errorf("expected the base fee burned and the tip charged, got balance %d and burned %d", s.Balances[from], s.totalBurned-burnedBefore)
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const TxTypeCheckpoint = TxType("checkpoint")
+
+const DefaultFinalityConfirmations = 6
+
+// FinalityConfig selects when blocks become irreversible. Proof of authority chains with a
+// CheckpointInterval finalize the checkpoint blocks signed by a majority of the validators,
+// other chains finalize the blocks buried under Confirmations blocks.
+type FinalityConfig struct{
+	Confirmations uint64 `json:"confirmations"`
+	CheckpointInterval uint64 `json:"checkpoint_interval,omitempty"`
+}
+
+var DefaultFinalityConfig = FinalityConfig{Confirmations: DefaultFinalityConfirmations}
+
+// Checkpoint is the block a validator signs as final
+type Checkpoint struct{
+	Number uint64 `json:"number"`
+	Hash Hash `json:"hash"`
+}
+
+func init(){
+	registerTxKind(TxTypeCheckpoint, TxKind{
+		Validate: validateCheckpointTx,
+		Apply: applyCheckpointTx,
+	})
+}
+
+// NewCheckpointTx signs the block at the checkpoint height as final, only validators can sign
+func NewCheckpointTx(from common.Address, number uint64, hash Hash, nonce uint) Tx{
+	return NewTypedTx(from, from, TxTypeCheckpoint, Checkpoint{number, hash}, 0, nonce)
+}
+
+func (s *State) usesCheckpoints() bool{
+	return s.finality.CheckpointInterval > 0 && len(s.Validators) > 0
+}
+
+// IsCheckpoint reports whether validators sign the block at the height as final
+func (s *State) IsCheckpoint(number uint64) bool{
+	return s.usesCheckpoints() && number > 0 && number%s.finality.CheckpointInterval == 0
+}
+
+// FinalizedNumber is the height of the last irreversible block, false while no block is final
+func (s *State) FinalizedNumber() (uint64, bool){
+	if s.usesCheckpoints(){
+		return s.finalized.Number, !s.finalized.Hash.IsEmpty()
+	}
+
+	latest := s.latestBlock.Header.Number
+	if !s.hasGenesisBlock || latest < s.finality.Confirmations{
+		return 0, false
+	}
+	return latest - s.finality.Confirmations, true
+}
+
+// validateNotFinalized refuses blocks replacing the finalized ones
+func validateNotFinalized(b Block, s *State) error{
+	finalized, ok := s.FinalizedNumber()
+	if ok && b.Header.Number <= finalized{
+		return fmt.Errorf("%w: block '%d' is at or below the finalized height '%d'", ErrFinalized, b.Header.Number, finalized)
+	}
+	return nil
+}
+
+// IsCheckpointPending reports whether the checkpoint still waits for the validators signatures
+func (s *State) IsCheckpointPending(checkpoint Checkpoint) bool{
+	hash, ok := s.checkpoints[checkpoint.Number]
+	return ok && hash == checkpoint.Hash
+}
+
+// recordCheckpoint keeps the hash of the checkpoint blocks for the validators to sign
+func (s *State) recordCheckpoint(b Block, hash Hash){
+	if s.IsCheckpoint(b.Header.Number){
+		s.checkpoints[b.Header.Number] = hash
+	}
+}
+
+func validateCheckpointTx(tx Tx, s *State) error{
+	checkpoint := Checkpoint{}
+	err := tx.DecodePayload(&checkpoint)
+	if err != nil{
+		return err
+	}
+
+	if !s.IsValidator(tx.From){
+		return fmt.Errorf("%w: '%s' can't sign checkpoints", ErrNotValidator, tx.From.String())
+	}
+	if !s.IsCheckpoint(checkpoint.Number){
+		return fmt.Errorf("%w: block '%d' isn't a checkpoint", ErrInvalidPayload, checkpoint.Number)
+	}
+
+	if !s.IsCheckpointPending(checkpoint){
+		return fmt.Errorf("%w: checkpoint '%d' '%x' is finalized, not reached yet or not on the chain", ErrInvalidPayload, checkpoint.Number, checkpoint.Hash)
+	}
+	if s.CheckpointVotes[checkpoint][tx.From]{
+		return fmt.Errorf("%w: '%s' already signed checkpoint '%d'", ErrInvalidPayload, tx.From.String(), checkpoint.Number)
+	}
+
+	return validateNoValue(tx)
+}
+
+// applyCheckpointTx records the signature and finalizes the checkpoint once signed by a
+// majority of the validators. Older checkpoints are final with it.
+func applyCheckpointTx(tx Tx, s *State) error{
+	checkpoint := Checkpoint{}
+	err := tx.DecodePayload(&checkpoint)
+	if err != nil{
+		return err
+	}
+
+	if s.CheckpointVotes[checkpoint] == nil{
+		s.CheckpointVotes[checkpoint] = make(map[common.Address]bool)
+	}
+	s.CheckpointVotes[checkpoint][tx.From] = true
+
+	if len(s.CheckpointVotes[checkpoint]) <= len(s.Validators)/2{
+		return nil
+	}
+
+	s.finalized = checkpoint
+
+	for c := range s.CheckpointVotes{
+		if c.Number <= checkpoint.Number{
+			delete(s.CheckpointVotes, c)
+		}
+	}
+	for number := range s.checkpoints{
+		if number <= checkpoint.Number{
+			delete(s.checkpoints, number)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFinalityConfirmations(t *testing.T){
+	s := newTestState(map[common.Address]uint{})
+	s.finality = FinalityConfig{Confirmations: 3}
+
+	s.hasGenesisBlock = true
+	s.latestBlock = NewBlock(Hash{}, 2, 0, 10, NewAccount("0x01"), nil)
+	if _, ok := s.FinalizedNumber(); ok{
+		t.Errorf("expected no finalized block below the confirmations depth")
+	}
+
+	s.latestBlock = NewBlock(Hash{}, 10, 0, 10, NewAccount("0x01"), nil)
+	number, ok := s.FinalizedNumber()
+	if !ok || number != 7{
+		t.Fatalf("expected block 7 to be finalized, got %d", number)
+	}
+
+	err := validateNotFinalized(NewBlock(Hash{}, 7, 0, 10, NewAccount("0x01"), nil), s)
+	if !errors.Is(err, ErrFinalized){
+		t.Errorf("expected %s replacing a finalized block, got %v", ErrFinalized, err)
+	}
+	err = validateNotFinalized(NewBlock(Hash{}, 8, 0, 10, NewAccount("0x01"), nil), s)
+	if err != nil{
+		t.Errorf("expected a block above the finalized height to pass, got %s", err)
+	}
+}
+
+func TestAddBlockBelowFinalized(t *testing.T){
+	dataDir := newTestBlockDB(t, 10)
+
+	s, err := NewStateFromDisk(dataDir, 0)
+	if err != nil{
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	finalized, ok := s.FinalizedNumber()
+	if !ok || finalized != 9-DefaultFinalityConfirmations{
+		t.Fatalf("expected block %d to be finalized, got %d", 9-DefaultFinalityConfirmations, finalized)
+	}
+
+	// A competing block from another miner at the finalized height
+	miner := NewAccount("0x02")
+	blockTime := uint64(1700000000 + finalized*10 + 5)
+	coinbase := NewCoinbaseTx(miner, finalized, blockTime, s.BlockRewardAt(finalized))
+	competing := NewBlock(Hash{}, finalized, 0, blockTime, miner, []SignedTx{coinbase})
+
+	_, err = s.AddBlock(mineTestBlock(competing))
+	if !errors.Is(err, ErrFinalized){
+		t.Errorf("expected %s for a block at the finalized height, got %v", ErrFinalized, err)
+	}
+	if s.LatestBlock().Header.Number != 9{
+		t.Errorf("expected the chain to stay at height 9, got %d", s.LatestBlock().Header.Number)
+	}
+}
+
+func TestCheckpointTx(t *testing.T){
+	keys, validators := newTestValidators(t, 3)
+
+	balances := make(map[common.Address]uint)
+	for _, validator := range validators{
+		balances[validator] = 10
+	}
+	s := newTestState(balances)
+	s.Validators = sortAccounts(validators)
+	s.finality = FinalityConfig{CheckpointInterval: 5}
+
+	checkpoint := NewBlock(Hash{}, 5, 0, 10, validators[0], nil)
+	hash, _ := checkpoint.Hash()
+	s.recordCheckpoint(checkpoint, hash)
+	s.recordCheckpoint(NewBlock(Hash{}, 6, 0, 10, validators[0], nil), Hash{1})
+
+	tests := []struct{
+		name string
+		tx Tx
+		err error
+	}{
+		{"not a checkpoint", NewCheckpointTx(validators[0], 6, Hash{1}, 1), ErrInvalidPayload},
+		{"wrong hash", NewCheckpointTx(validators[0], 5, Hash{1}, 1), ErrInvalidPayload},
+		{"not reached", NewCheckpointTx(validators[0], 10, hash, 1), ErrInvalidPayload},
+	}
+
+	for _, test := range tests{
+		err := ValidateTx(signTestTx(t, test.tx, keys[0]), s)
+		if !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err)
+		}
+	}
+
+	for i := 0; i < 2; i++{
+		if _, ok := s.FinalizedNumber(); ok{
+			t.Fatalf("expected no finalized block before a majority signed")
+		}
+
+		err := ApplyTx(signTestTx(t, NewCheckpointTx(validators[i], 5, hash, 1), keys[i]), s)
+		if err != nil{
+			t.Fatal(err)
+		}
+	}
+
+	number, ok := s.FinalizedNumber()
+	if !ok || number != 5{
+		t.Fatalf("expected checkpoint 5 to be finalized, got %d", number)
+	}
+
+	err := ValidateTx(signTestTx(t, NewCheckpointTx(validators[2], 5, hash, 1), keys[2]), s)
+	if !errors.Is(err, ErrInvalidPayload){
+		t.Errorf("expected %s signing a finalized checkpoint, got %v", ErrInvalidPayload, err)
+	}
+}
@@ -1,11 +1,15 @@
 package core 
 
 import (
+	"bytes" 
 	"io/ioutil" 
 	"os" 
 	"path/filepath"
 )
 
+// dbTailChunkSize is read at a time searching the last record of a db file backwards 
+const dbTailChunkSize = 4096
+
 func InitDataDirIfNotExists(dataDir string, genesis []byte) error{
 	if fileExists(getGenesisJsonFilePath(dataDir)){
 		return nil 
@@ -58,4 +62,64 @@ func dirExists(path string) (bool, error){
 
 func writeEmptyBlockDbToDisk(path string) error{
 	return ioutil.WriteFile(path, []byte(""), os.ModePerm)
-}
\ No newline at end of file
+}
+// appendRecord writes the record and its newline in one write and flushes it to disk. 
+// A failed write is cut off so the next record starts on a clean line. 
+func appendRecord(f *os.File, record []byte) error{
+	info, err := f.Stat() 
+	if err != nil{
+		return err 
+	}
+
+	_, err = f.Write(append(record, '\n')) 
+	if err != nil{
+		_ = f.Truncate(info.Size()) 
+		return err 
+	}
+
+	return f.Sync() 
+}
+
+// truncatePartialRecord drops the bytes after the last newline of a db file, left by a crash 
+// in the middle of a record write, and returns how many bytes were dropped 
+func truncatePartialRecord(f *os.File) (int64, error){
+	info, err := f.Stat() 
+	if err != nil{
+		return 0, err 
+	}
+
+	size := info.Size() 
+	end := size 
+	buf := make([]byte, dbTailChunkSize) 
+
+	for end > 0{
+		start := end - dbTailChunkSize 
+		if start < 0{
+			start = 0 
+		}
+
+		chunk := buf[:end-start] 
+		_, err = f.ReadAt(chunk, start) 
+		if err != nil{
+			return 0, err 
+		}
+
+		i := bytes.LastIndexByte(chunk, '\n') 
+		if i >= 0{
+			end = start + int64(i) + 1 
+			break
+		}
+		end = start 
+	}
+
+	if end == size{
+		return 0, nil 
+	}
+
+	err = f.Truncate(end) 
+	if err != nil{
+		return 0, err 
+	}
+
+	return size - end, f.Sync() 
+}
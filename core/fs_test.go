@@ -1 +1,59 @@
-package core
\ No newline at end of file
+package core
+
+import (
+	"os" 
+	"path/filepath" 
+	"strings" 
+	"testing" 
+)
+
+func TestTruncatePartialRecord(t *testing.T){
+	tests := []struct{
+		content string 
+		expected string 
+	}{
+		{"", ""}, 
+		{"{\"a\":1}\n", "{\"a\":1}\n"}, 
+		{"{\"a\":1}\n{\"b\":", "{\"a\":1}\n"}, 
+		{"{\"a\":1}\n{\"b\":2}\n{\"c\"", "{\"a\":1}\n{\"b\":2}\n"}, 
+		{"{\"a\"", ""}, 
+		// A partial record longer than the chunk read backwards 
+		{"{\"a\":1}\n" + strings.Repeat("x", 2*dbTailChunkSize+7), "{\"a\":1}\n"}, 
+	}
+
+	for _, test := range tests{
+		path := filepath.Join(t.TempDir(), "block.db") 
+		err := os.WriteFile(path, []byte(test.content), 0600) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_RDWR, 0600) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+
+		dropped, err := truncatePartialRecord(f) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		if dropped != int64(len(test.content)-len(test.expected)){
+			t.Errorf("expected %d dropped bytes, got %d", len(test.content)-len(test.expected), dropped) 
+		}
+
+		// The next record starts on a clean line 
+		err = appendRecord(f, []byte("{\"d\":4}")) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		f.Close() 
+
+		content, err := os.ReadFile(path) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		if string(content) != test.expected+"{\"d\":4}\n"{
+			t.Errorf("unexpected db content %q", content) 
+		}
+	}
+}
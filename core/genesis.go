@@ -13,12 +13,45 @@ var genesisJson = `{
 	"symbol": "NEM", 
 	"balances":{
 		"0x09eE50f2F37FcBA1845dE6FE5C762E83E65E755c": 1000000
+	}, 
+	"emission":{
+		"initial_reward": 100, 
+		"halving_interval": 210000, 
+		"tail_emission": 1, 
+		"max_supply": 50000000
 	}
 }`
 
 type Genesis struct{
 	Balances map[common.Address]uint `json:"balances"`
 	Symbol string			`json:"symbol"`
+	Emission *EmissionSchedule `json:"emission"`
+	Consensus *ConsensusConfig `json:"consensus,omitempty"`
+	Finality *FinalityConfig `json:"finality,omitempty"`
+}
+
+// EmissionSchedule falls back to the constant reward when genesis doesn't define one
+func (g Genesis) EmissionSchedule() EmissionSchedule{
+	if g.Emission == nil{
+		return DefaultEmissionSchedule
+	}
+	return *g.Emission
+}
+
+// ConsensusConfig falls back to proof of work when genesis doesn't define one
+func (g Genesis) ConsensusConfig() ConsensusConfig{
+	if g.Consensus == nil{
+		return DefaultConsensusConfig
+	}
+	return *g.Consensus
+}
+
+// FinalityConfig falls back to DefaultFinalityConfirmations when genesis doesn't define one
+func (g Genesis) FinalityConfig() FinalityConfig{
+	if g.Finality == nil{
+		return DefaultFinalityConfig
+	}
+	return *g.Finality
 }
 
 func loadGenesis(path string) (Genesis, error){
@@ -0,0 +1,173 @@
+package core 
+
+import (
+	"bufio" 
+	"encoding/json" 
+	"fmt" 
+	"os" 
+	"path/filepath"
+	"sync" 
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// HeaderChain is the storage of light nodes: only the validated block headers are kept. 
+// It is safe for concurrent use, headers are synced while the API reads them.
+type HeaderChain struct{
+	mu sync.RWMutex 
+	dbFile *os.File 
+
+	headers []BlockHeaderFS 
+	byHash map[Hash]int 
+
+	// Validators sealing the next header, following the changes announced by the headers
+	engine Engine 
+	validators []common.Address 
+}
+
+func getHeadersDbFilePath(dataDir string) string{
+	return filepath.Join(getDatabaseDirPath(dataDir), "headers.db")
+}
+
+func NewHeaderChainFromDisk(dataDir string, miningDifficulty uint) (*HeaderChain, error){
+	err := InitDataDirIfNotExists(dataDir, []byte(genesisJson))
+	if err != nil{
+		return nil, err 
+	}
+
+	gen, err := loadGenesis(getGenesisJsonFilePath(dataDir)) 
+	if err != nil{
+		return nil, err 
+	}
+
+	consensus := gen.ConsensusConfig() 
+	engine, err := NewEngine(consensus, miningDifficulty) 
+	if err != nil{
+		return nil, err 
+	}
+
+	f, err := os.OpenFile(getHeadersDbFilePath(dataDir), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600) 
+	if err != nil{
+		return nil, err 
+	}
+
+	dropped, err := truncatePartialRecord(f) 
+	if err != nil{
+		return nil, err 
+	}
+	if dropped > 0{
+		stateLog().Warn("dropped the partially written last header", "file", getHeadersDbFilePath(dataDir), "bytes", dropped) 
+	}
+
+	c := &HeaderChain{
+		dbFile: f, 
+		headers: make([]BlockHeaderFS, 0), 
+		byHash: make(map[Hash]int), 
+		engine: engine, 
+		validators: sortAccounts(consensus.Validators), 
+	}
+
+	loaded := make([]BlockHeaderFS, 0) 
+
+	scanner := bufio.NewScanner(f) 
+	for scanner.Scan(){
+		if len(scanner.Bytes()) == 0{
+			break
+		}
+
+		var header BlockHeaderFS 
+		err = json.Unmarshal(scanner.Bytes(), &header) 
+		if err != nil{
+			return nil, err 
+		}
+		loaded = append(loaded, header) 
+	}
+	if err := scanner.Err(); err != nil{
+		return nil, err 
+	}
+
+	err = ValidateHeaderChain(loaded, BlockHeaderFS{}, false, c.engine, c.validators) 
+	if err != nil{
+		return nil, err 
+	}
+	c.index(loaded) 
+
+	return c, nil 
+}
+
+// AddHeaders validates the headers extend the chain and persists them. Legacy headers 
+// are refused: their hash covers the block body so the announced one can't be trusted.
+func (c *HeaderChain) AddHeaders(headers []BlockHeaderFS) error{
+	c.mu.Lock() 
+	defer c.mu.Unlock() 
+
+	for _, h := range headers{
+		if h.Value.IsLegacy(){
+			return fmt.Errorf("%w: header '%d' can't be verified without its block body", ErrLegacyBlock, h.Value.Number)
+		}
+	}
+
+	tip, hasTip := c.latest() 
+
+	err := ValidateHeaderChain(headers, tip, hasTip, c.engine, c.validators) 
+	if err != nil{
+		return err 
+	}
+
+	for _, h := range headers{
+		headerJson, err := json.Marshal(h) 
+		if err != nil{
+			return err 
+		}
+
+		err = appendRecord(c.dbFile, headerJson) 
+		if err != nil{
+			return err 
+		}
+	}
+
+	c.index(headers) 
+
+	return nil 
+}
+
+func (c *HeaderChain) index(headers []BlockHeaderFS){
+	for _, h := range headers{
+		c.byHash[h.Key] = len(c.headers) 
+		c.headers = append(c.headers, h) 
+	}
+	c.validators = ValidatorsAfter(c.validators, headers) 
+}
+
+// Latest returns the chain tip, false if no header was synced yet
+func (c *HeaderChain) Latest() (BlockHeaderFS, bool){
+	c.mu.RLock() 
+	defer c.mu.RUnlock() 
+
+	return c.latest() 
+}
+
+func (c *HeaderChain) latest() (BlockHeaderFS, bool){
+	if len(c.headers) == 0{
+		return BlockHeaderFS{}, false 
+	}
+	return c.headers[len(c.headers)-1], true 
+}
+
+func (c *HeaderChain) GetByHash(hash Hash) (BlockHeader, bool){
+	c.mu.RLock() 
+	defer c.mu.RUnlock() 
+
+	i, ok := c.byHash[hash] 
+	if !ok{
+		return BlockHeader{}, false 
+	}
+	return c.headers[i].Value, true 
+}
+
+func (c *HeaderChain) Close() error{
+	c.mu.Lock() 
+	defer c.mu.Unlock() 
+
+	return c.dbFile.Close() 
+}
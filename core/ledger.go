@@ -9,12 +9,44 @@ import (
 )
 
 func GetBlockAfter(blockHash Hash, dataDir string) ([]Block, error){
-	f, err := os.OpenFile(getBlocksDbFilePath(dataDir), os.O_RDONLY, 0600)
+	return GetBlocksAfter(blockHash, 0, dataDir)
+}
+
+// GetBlocksAfter returns at most limit blocks following blockHash. 
+// A limit <= 0 returns all of them.
+func GetBlocksAfter(blockHash Hash, limit int, dataDir string) ([]Block, error){
+	blocks := make([]Block, 0) 
+
+	err := scanBlocksAfter(blockHash, limit, dataDir, func(blockFs BlockFS){
+		blocks = append(blocks, blockFs.Value)
+	})
 	if err != nil{
 		return nil, err 
 	}
+	return blocks, nil 
+}
+
+// GetBlockHeadersAfter returns at most limit headers, with their block hash, following blockHash
+func GetBlockHeadersAfter(blockHash Hash, limit int, dataDir string) ([]BlockHeaderFS, error){
+	headers := make([]BlockHeaderFS, 0) 
 
-	blocks := make([]Block, 0)
+	err := scanBlocksAfter(blockHash, limit, dataDir, func(blockFs BlockFS){
+		headers = append(headers, BlockHeaderFS{blockFs.Key, blockFs.Value.Header})
+	})
+	if err != nil{
+		return nil, err 
+	}
+	return headers, nil 
+}
+
+func scanBlocksAfter(blockHash Hash, limit int, dataDir string, collect func(BlockFS)) error{
+	f, err := os.OpenFile(getBlocksDbFilePath(dataDir), os.O_RDONLY, 0600)
+	if err != nil{
+		return err 
+	}
+	defer f.Close() 
+
+	collected := 0 
 	shouldStartCollecting:= false 
 
 	if reflect.DeepEqual(blockHash, Hash{}){
@@ -23,18 +55,19 @@ func GetBlockAfter(blockHash Hash, dataDir string) ([]Block, error){
 
 	scanner := bufio.NewScanner(f) 
 	for scanner.Scan(){
-		if err := scanner.Err(); err != nil{
-			return nil, err 
+		if limit > 0 && collected >= limit{
+			break
 		}
 
 		var blockFs BlockFS 
 		err = json.Unmarshal(scanner.Bytes(), &blockFs) 
 		if err != nil{
-			return nil, err
+			return err
 		}
 
 		if shouldStartCollecting{
-			blocks = append(blocks, blockFs.Value)
+			collect(blockFs) 
+			collected++ 
 			continue 
 		}
 
@@ -42,7 +75,7 @@ func GetBlockAfter(blockHash Hash, dataDir string) ([]Block, error){
 			shouldStartCollecting = true 
 		}
 	}
-	return blocks, nil 
+	return scanner.Err()
 }
 
 // GetBlocksByHeightOrHash returns the requested block by height or hash
@@ -57,9 +90,9 @@ func GetBlockByHeightOrHash(state *State, height uint64, hash, dataDir string) (
 
 	if !ok{
 		if hash != ""{
-			return block, fmt.Errorf("invalid hash: '%v'", hash)
+			return block, fmt.Errorf("%w: invalid hash: '%v'", ErrNotFound, hash)
 		}
-		return block, fmt.Errorf("invalid height: '%v'", height) 
+		return block, fmt.Errorf("%w: invalid height: '%v'", ErrNotFound, height) 
 	}
 
 	f, err := os.OpenFile(getBlocksDbFilePath(dataDir), os.O_RDONLY, 0600)
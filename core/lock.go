@@ -0,0 +1,274 @@
+package core 
+
+import (
+	"crypto/sha256" 
+	"fmt" 
+	"sort" 
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Locked transfers debit the sender right away but only credit the recipient 
+// once claimed. Time locks are claimable from a block height and/or time, 
+// hash time locks are claimable with the hash preimage until they expire, 
+// after which the sender can refund them. Anyone can submit the claim, the value 
+// always goes to the recipient, so a recipient without funds doesn't need to pay its fee.
+const TxTypeTimeLock = TxType("timelock")
+const TxTypeHashTimeLock = TxType("htlc")
+const TxTypeLockClaim = TxType("lock_claim")
+const TxTypeLockRefund = TxType("lock_refund")
+
+type Lock struct{
+	ID Hash `json:"id"`
+	From common.Address `json:"from"`
+	To common.Address `json:"to"`
+	Value uint `json:"value"`
+	UnlockHeight uint64 `json:"unlock_height,omitempty"`
+	UnlockTime uint64 `json:"unlock_time,omitempty"`
+	HashLock Hash `json:"hash_lock,omitempty"`
+	ExpiryHeight uint64 `json:"expiry_height,omitempty"`
+}
+
+type TimeLockPayload struct{
+	UnlockHeight uint64 `json:"unlock_height"`
+	UnlockTime uint64 `json:"unlock_time"`
+}
+
+type HashTimeLockPayload struct{
+	HashLock Hash `json:"hash_lock"`
+	ExpiryHeight uint64 `json:"expiry_height"`
+}
+
+type LockClaimPayload struct{
+	LockID Hash `json:"lock_id"`
+	Preimage []byte `json:"preimage,omitempty"`
+}
+
+type LockRefundPayload struct{
+	LockID Hash `json:"lock_id"`
+}
+
+func init(){
+	registerTxKind(TxTypeTimeLock, TxKind{
+		Validate: validateTimeLockTx, 
+		Apply: applyLockTx, 
+	})
+	registerTxKind(TxTypeHashTimeLock, TxKind{
+		Validate: validateHashTimeLockTx, 
+		Apply: applyLockTx, 
+	})
+	registerTxKind(TxTypeLockClaim, TxKind{
+		Validate: validateLockClaimTx, 
+		Apply: applyLockClaimTx, 
+	})
+	registerTxKind(TxTypeLockRefund, TxKind{
+		Validate: validateLockRefundTx, 
+		Apply: applyLockRefundTx, 
+	})
+}
+
+func NewTimeLockTx(from, to common.Address, value uint, unlockHeight, unlockTime uint64, nonce uint) Tx{
+	return NewTypedTx(from, to, TxTypeTimeLock, TimeLockPayload{unlockHeight, unlockTime}, value, nonce)
+}
+
+// NewHashTimeLockTx locks the value for the recipient until expiryHeight, 
+// hashLock is the sha256 of the secret preimage revealed to claim it
+func NewHashTimeLockTx(from, to common.Address, value uint, hashLock Hash, expiryHeight uint64, nonce uint) Tx{
+	return NewTypedTx(from, to, TxTypeHashTimeLock, HashTimeLockPayload{hashLock, expiryHeight}, value, nonce)
+}
+
+func NewLockClaimTx(from common.Address, lockID Hash, preimage []byte, nonce uint) Tx{
+	return NewTypedTx(from, from, TxTypeLockClaim, LockClaimPayload{lockID, preimage}, 0, nonce)
+}
+
+func NewLockRefundTx(from common.Address, lockID Hash, nonce uint) Tx{
+	return NewTypedTx(from, from, TxTypeLockRefund, LockRefundPayload{lockID}, 0, nonce)
+}
+
+// IsUnlocked reports whether a time lock can be claimed in the block at the given height, 
+// the unlock time is compared with the time of its parent
+func (l Lock) IsUnlocked(height uint64, parentTime uint64) bool{
+	return height >= l.UnlockHeight && parentTime >= l.UnlockTime 
+}
+
+func (l Lock) IsHashLock() bool{
+	return !l.HashLock.IsEmpty()
+}
+
+func validateLockValue(tx Tx) error{
+	if tx.Value == 0{
+		return fmt.Errorf("%w: %s txs must lock a value", ErrInvalidPayload, tx.Kind())
+	}
+	if tx.To == (common.Address{}){
+		return fmt.Errorf("%w: %s txs need a recipient", ErrInvalidPayload, tx.Kind())
+	}
+	return nil 
+}
+
+func validateTimeLockTx(tx Tx, s *State) error{
+	payload := TimeLockPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	if payload.UnlockHeight == 0 && payload.UnlockTime == 0{
+		return fmt.Errorf("%w: a time lock needs an unlock height or time", ErrInvalidPayload)
+	}
+
+	return validateLockValue(tx)
+}
+
+func validateHashTimeLockTx(tx Tx, s *State) error{
+	payload := HashTimeLockPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	if payload.HashLock.IsEmpty(){
+		return fmt.Errorf("%w: a hash time lock needs a hash lock", ErrInvalidPayload)
+	}
+	if payload.ExpiryHeight <= s.NextBlockNumber(){
+		return fmt.Errorf("%w: expiry height '%d' is already reached", ErrInvalidPayload, payload.ExpiryHeight)
+	}
+
+	return validateLockValue(tx)
+}
+
+// applyLockTx moves the value from the sender balance to a lock identified by the tx hash
+func applyLockTx(tx Tx, s *State) error{
+	id, err := tx.Hash() 
+	if err != nil{
+		return err 
+	}
+
+	lock := Lock{ID: id, From: tx.From, To: tx.To, Value: tx.Value}
+
+	if tx.Type == TxTypeTimeLock{
+		payload := TimeLockPayload{} 
+		err = tx.DecodePayload(&payload) 
+		lock.UnlockHeight, lock.UnlockTime = payload.UnlockHeight, payload.UnlockTime 
+	} else{
+		payload := HashTimeLockPayload{} 
+		err = tx.DecodePayload(&payload) 
+		lock.HashLock, lock.ExpiryHeight = payload.HashLock, payload.ExpiryHeight 
+	}
+	if err != nil{
+		return err 
+	}
+
+	s.Balances[tx.From] -= tx.Value 
+	s.Locks[id] = lock 
+
+	return nil 
+}
+
+func validateLockClaimTx(tx Tx, s *State) error{
+	payload := LockClaimPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	lock, ok := s.Locks[payload.LockID] 
+	if !ok{
+		return fmt.Errorf("%w: lock '%s'", ErrNotFound, payload.LockID.Hex())
+	}
+	height := s.NextBlockNumber() 
+
+	if !lock.IsHashLock(){
+		if !lock.IsUnlocked(height, s.latestBlock.Header.Time){
+			return fmt.Errorf("%w: lock '%s' unlocks at height '%d' and time '%d'", ErrLocked, lock.ID.Hex(), lock.UnlockHeight, lock.UnlockTime)
+		}
+		return validateNoValue(tx)
+	}
+
+	if height >= lock.ExpiryHeight{
+		return fmt.Errorf("%w: lock '%s' expired at height '%d'", ErrLocked, lock.ID.Hex(), lock.ExpiryHeight)
+	}
+	if Hash(sha256.Sum256(payload.Preimage)) != lock.HashLock{
+		return fmt.Errorf("%w: preimage doesn't match lock '%s'", ErrInvalidPayload, lock.ID.Hex())
+	}
+
+	return validateNoValue(tx)
+}
+
+func applyLockClaimTx(tx Tx, s *State) error{
+	payload := LockClaimPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	lock := s.Locks[payload.LockID] 
+	s.Balances[lock.To] += lock.Value 
+	delete(s.Locks, lock.ID) 
+
+	return nil 
+}
+
+func validateLockRefundTx(tx Tx, s *State) error{
+	payload := LockRefundPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	lock, ok := s.Locks[payload.LockID] 
+	if !ok{
+		return fmt.Errorf("%w: lock '%s'", ErrNotFound, payload.LockID.Hex())
+	}
+	if !lock.IsHashLock(){
+		return fmt.Errorf("%w: time lock '%s' can't be refunded", ErrInvalidPayload, lock.ID.Hex())
+	}
+	if lock.From != tx.From{
+		return fmt.Errorf("%w: lock '%s' can only be refunded to '%s'", ErrInvalidPayload, lock.ID.Hex(), lock.From.String())
+	}
+	if s.NextBlockNumber() < lock.ExpiryHeight{
+		return fmt.Errorf("%w: lock '%s' can be refunded from height '%d'", ErrLocked, lock.ID.Hex(), lock.ExpiryHeight)
+	}
+
+	return validateNoValue(tx)
+}
+
+func applyLockRefundTx(tx Tx, s *State) error{
+	payload := LockRefundPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	lock := s.Locks[payload.LockID] 
+	s.Balances[lock.From] += lock.Value 
+	delete(s.Locks, lock.ID) 
+
+	return nil 
+}
+
+// AccountLocks returns the locks sent or owed to the account, sorted by id
+func (s *State) AccountLocks(account common.Address) []Lock{
+	locks := make([]Lock, 0) 
+	for _, lock := range s.Locks{
+		if lock.From == account || lock.To == account{
+			locks = append(locks, lock)
+		}
+	}
+
+	sort.Slice(locks, func(i, j int) bool{
+		return locks[i].ID.Hex() < locks[j].ID.Hex()
+	})
+
+	return locks 
+}
+
+// LockedBalance is the value locked for the account, not yet claimed
+func (s *State) LockedBalance(account common.Address) uint{
+	locked := uint(0) 
+	for _, lock := range s.Locks{
+		if lock.To == account{
+			locked += lock.Value
+		}
+	}
+	return locked 
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestLockTxs(t *testing.T){
+	senderKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	recipientKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	sender := crypto.PubkeyToAddress(senderKey.PublicKey) 
+	recipient := crypto.PubkeyToAddress(recipientKey.PublicKey) 
+
+	s := newTestState(map[common.Address]uint{sender: 100, recipient: 10}) 
+	s.hasGenesisBlock = true 
+	s.latestBlock = Block{Header: BlockHeader{Number: 5, Time: 50}} 
+
+	timeLock := NewTimeLockTx(sender, recipient, 20, 10, 0, 1) 
+	secret := []byte("secret") 
+	hashLock := NewHashTimeLockTx(sender, recipient, 30, sha256.Sum256(secret), 8, 2) 
+
+	for i, tx := range []Tx{timeLock, hashLock}{
+		err = ApplyTx(signTestTx(t, tx, senderKey), s) 
+		if err != nil{
+			t.Fatalf("lock %d: %s", i, err)
+		}
+	}
+
+	if s.LockedBalance(recipient) != 50 || len(s.AccountLocks(sender)) != 2{
+		t.Fatalf("expected 50 locked in 2 locks, got %d", s.LockedBalance(recipient))
+	}
+
+	timeLockID, _ := timeLock.Hash() 
+	hashLockID, _ := hashLock.Hash() 
+
+	tests := []struct{
+		name string 
+		tx SignedTx 
+		err error 
+	}{
+		{"time lock early", signTestTx(t, NewLockClaimTx(recipient, timeLockID, nil, 1), recipientKey), ErrLocked}, 
+		{"wrong preimage", signTestTx(t, NewLockClaimTx(recipient, hashLockID, []byte("guess"), 1), recipientKey), ErrInvalidPayload}, 
+		{"refund before expiry", signTestTx(t, NewLockRefundTx(sender, hashLockID, 3), senderKey), ErrLocked}, 
+		{"unknown lock", signTestTx(t, NewLockClaimTx(recipient, Hash{1}, nil, 1), recipientKey), ErrNotFound}, 
+	}
+
+	for _, test := range tests{
+		err := ValidateTx(test.tx, s) 
+		if !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err) 
+		}
+	}
+
+	err = ApplyTx(signTestTx(t, NewLockClaimTx(recipient, hashLockID, secret, 1), recipientKey), s) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	if s.Balances[recipient] != 10+30-TxGas*TxGasPriceDefault || s.LockedBalance(recipient) != 20{
+		t.Errorf("expected the hash lock claimed, got balance %d and locked %d", s.Balances[recipient], s.LockedBalance(recipient))
+	}
+
+	// The sender pays the fee of the claim, the value still goes to the recipient
+	s.latestBlock.Header.Number = 9 
+	recipientBalance := s.Balances[recipient] 
+	err = ApplyTx(signTestTx(t, NewLockClaimTx(sender, timeLockID, nil, 3), senderKey), s) 
+	if err != nil{
+		t.Fatalf("expected the time lock to be claimable by anyone, got %s", err)
+	}
+	if s.Balances[recipient] != recipientBalance+20 || s.LockedBalance(recipient) != 0{
+		t.Errorf("expected the time lock paid to the recipient, got balance %d", s.Balances[recipient])
+	}
+}
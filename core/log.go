@@ -0,0 +1,17 @@
+package core 
+
+import (
+	"log/slog" 
+)
+
+const logKeyComponent = "component" 
+
+// The loggers wrap slog.Default on every call so the logger set up by `nemos run` applies 
+
+func stateLog() *slog.Logger{
+	return slog.Default().With(logKeyComponent, "state") 
+}
+
+func minerLog() *slog.Logger{
+	return slog.Default().With(logKeyComponent, "miner") 
+}
@@ -0,0 +1,65 @@
+package core 
+
+import (
+	"time" 
+
+	"github.com/prometheus/client_golang/prometheus" 
+	"github.com/prometheus/client_golang/prometheus/promauto" 
+)
+
+// The chain metrics are registered in the default prometheus registry served by the node 
+var (
+	chainHeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "nemos_chain_height", 
+		Help: "Number of the latest block.", 
+	})
+	chainHeadTime = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "nemos_chain_head_timestamp_seconds", 
+		Help: "Timestamp of the latest block.", 
+	})
+	chainBlockTime = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "nemos_chain_block_time_seconds", 
+		Help: "Seconds between the timestamps of consecutive blocks.", 
+		Buckets: []float64{1, 2, 5, 10, 15, 30, 60, 120, 300, 600}, 
+	})
+	chainBlocksAdded = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nemos_chain_blocks_added_total", 
+		Help: "Blocks added to the chain since the node started.", 
+	})
+	chainTxsAdded = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nemos_chain_txs_added_total", 
+		Help: "Txs included in the blocks added since the node started.", 
+	})
+	miningHashes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nemos_mining_hashes_total", 
+		Help: "Block hashes computed searching proof of work nonces.", 
+	})
+	miningHashrate = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "nemos_mining_hashrate", 
+		Help: "Hashes per second of the latest proof of work search.", 
+	})
+)
+
+func observeHead(b Block){
+	chainHeight.Set(float64(b.Header.Number)) 
+	chainHeadTime.Set(float64(b.Header.Time)) 
+}
+
+// observeNewBlock records a block added on top of a parent stamped at parentTime 
+func observeNewBlock(b Block, parentTime uint64, hasParent bool){
+	observeHead(b) 
+	chainBlocksAdded.Inc() 
+	chainTxsAdded.Add(float64(len(b.Txs))) 
+
+	if hasParent && b.Header.Time >= parentTime{
+		chainBlockTime.Observe(float64(b.Header.Time - parentTime)) 
+	}
+}
+
+func observeMining(hashes int, elapsed time.Duration){
+	miningHashes.Add(float64(hashes)) 
+
+	if elapsed > 0{
+		miningHashrate.Set(float64(hashes) / elapsed.Seconds()) 
+	}
+}
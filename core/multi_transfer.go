@@ -0,0 +1,97 @@
+package core 
+
+import (
+	"fmt" 
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const TxTypeMultiTransfer = TxType("multi_transfer")
+
+// A multi transfer pays TxGasPerOutput for each of its outputs
+const TxGasPerOutput = TxGas
+const MaxTxOutputs = 500
+
+type TxOutput struct{
+	To common.Address `json:"to"`
+	Value uint `json:"value"`
+}
+
+// MultiTransferPayload lists the recipients of a multi transfer. 
+// The tx Value must be the sum of the outputs values.
+type MultiTransferPayload struct{
+	Outputs []TxOutput `json:"outputs"`
+}
+
+func init(){
+	registerTxKind(TxTypeMultiTransfer, TxKind{
+		Gas: multiTransferGas, 
+		Validate: validateMultiTransferTx, 
+		Apply: applyMultiTransferTx, 
+	})
+}
+
+// NewMultiTransferTx creates a tx paying all the outputs at once with a single nonce and signature
+func NewMultiTransferTx(from common.Address, outputs []TxOutput, nonce uint) Tx{
+	total := uint(0) 
+	for _, out := range outputs{
+		total += out.Value 
+	}
+
+	tx := NewTypedTx(from, common.Address{}, TxTypeMultiTransfer, MultiTransferPayload{outputs}, total, nonce) 
+	tx.Gas = multiTransferGas(tx) 
+
+	return tx 
+}
+
+func multiTransferGas(tx Tx) uint{
+	payload := MultiTransferPayload{} 
+	if tx.DecodePayload(&payload) != nil || len(payload.Outputs) == 0{
+		return TxGasPerOutput 
+	}
+	return TxGasPerOutput * uint(len(payload.Outputs))
+}
+
+func validateMultiTransferTx(tx Tx, s *State) error{
+	payload := MultiTransferPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	if len(payload.Outputs) == 0 || len(payload.Outputs) > MaxTxOutputs{
+		return fmt.Errorf("%w: a multi transfer needs 1 to %d outputs, got %d", ErrInvalidPayload, MaxTxOutputs, len(payload.Outputs))
+	}
+
+	total := uint(0) 
+	for i, out := range payload.Outputs{
+		if out.Value == 0{
+			return fmt.Errorf("%w: output %d has no value", ErrInvalidPayload, i)
+		}
+		if total + out.Value < total{
+			return fmt.Errorf("%w: outputs total overflows", ErrInvalidPayload)
+		}
+		total += out.Value 
+	}
+
+	if total != tx.Value{
+		return fmt.Errorf("%w: tx value must be the outputs total '%d' not '%d'", ErrInvalidPayload, total, tx.Value)
+	}
+
+	return nil 
+}
+
+func applyMultiTransferTx(tx Tx, s *State) error{
+	payload := MultiTransferPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	s.Balances[tx.From] -= tx.Value 
+	for _, out := range payload.Outputs{
+		s.Balances[out.To] += out.Value
+	}
+
+	return nil 
+}
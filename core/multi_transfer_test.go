@@ -0,0 +1,60 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestMultiTransferTx(t *testing.T){
+	privKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	from := crypto.PubkeyToAddress(privKey.PublicKey) 
+	alice, bob := NewAccount("0x02"), NewAccount("0x03") 
+
+	outputs := []TxOutput{{alice, 30}, {bob, 20}} 
+
+	tooMuch := NewMultiTransferTx(from, []TxOutput{{alice, 60}, {bob, 40}}, 1) 
+	wrongValue := NewMultiTransferTx(from, outputs, 1) 
+	wrongValue.Value = 10 
+	noGas := NewMultiTransferTx(from, outputs, 1) 
+	noGas.Gas = TxGas 
+
+	tests := []struct{
+		name string 
+		tx Tx 
+		err error 
+	}{
+		{"balance", tooMuch, ErrInsufficientBalance}, 
+		{"value", wrongValue, ErrInvalidPayload}, 
+		{"gas", noGas, ErrInsufficientGas}, 
+		{"empty", NewMultiTransferTx(from, nil, 1), ErrInvalidPayload}, 
+	}
+
+	s := newTestState(map[common.Address]uint{from: 100}) 
+
+	for _, test := range tests{
+		err := ValidateTx(signTestTx(t, test.tx, privKey), s) 
+		if !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err) 
+		}
+	}
+
+	tx := NewMultiTransferTx(from, outputs, 1) 
+	if tx.Gas != 2*TxGasPerOutput{
+		t.Errorf("expected gas for 2 outputs, got %d", tx.Gas)
+	}
+
+	err = ApplyTx(signTestTx(t, tx, privKey), s) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	if s.Balances[alice] != 30 || s.Balances[bob] != 20 || s.Balances[from] != 100-50-tx.GasCost(){
+		t.Errorf("unexpected balances %v", s.Balances)
+	}
+}
@@ -0,0 +1,138 @@
+package core 
+
+import (
+	"bytes" 
+	"encoding/json" 
+	"fmt" 
+	"sort" 
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const TxTypeMultisigRegister = TxType("multisig_register")
+
+const MaxMultisigSigners = 16
+
+// MultisigAccount is spent by txs carrying signatures of at least Threshold of its Signers
+type MultisigAccount struct{
+	Signers []common.Address `json:"signers"`
+	Threshold uint `json:"threshold"`
+}
+
+func init(){
+	registerTxKind(TxTypeMultisigRegister, TxKind{
+		Validate: validateMultisigRegisterTx, 
+		Apply: applyMultisigRegisterTx, 
+	})
+}
+
+func NewMultisigAccount(signers []common.Address, threshold uint) MultisigAccount{
+	sorted := append([]common.Address(nil), signers...) 
+	sort.Slice(sorted, func(i, j int) bool{
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 
+	})
+
+	return MultisigAccount{sorted, threshold}
+}
+
+// Address of the multisig account, derived from its signers and threshold
+func (m MultisigAccount) Address() common.Address{
+	// Addresses and numbers always encode
+	encoded, _ := json.Marshal(NewMultisigAccount(m.Signers, m.Threshold)) 
+	return common.BytesToAddress(crypto.Keccak256(encoded)[12:])
+}
+
+func (m MultisigAccount) isSigner(account common.Address) bool{
+	for _, signer := range m.Signers{
+		if signer == account{
+			return true
+		}
+	}
+	return false
+}
+
+// NewMultisigRegisterTx registers the multisig account of the signers, the sender pays the fees
+func NewMultisigRegisterTx(from common.Address, signers []common.Address, threshold uint, nonce uint) Tx{
+	account := NewMultisigAccount(signers, threshold) 
+	return NewTypedTx(from, account.Address(), TxTypeMultisigRegister, account, 0, nonce)
+}
+
+// NewMultisigTx combines the signatures of the tx collected from the multisig signers
+func NewMultisigTx(tx Tx, sigs [][]byte) SignedTx{
+	return SignedTx{Tx: tx, Sigs: sigs}
+}
+
+func validateMultisigRegisterTx(tx Tx, s *State) error{
+	account := MultisigAccount{} 
+	err := tx.DecodePayload(&account) 
+	if err != nil{
+		return err 
+	}
+
+	if len(account.Signers) < 2 || len(account.Signers) > MaxMultisigSigners{
+		return fmt.Errorf("%w: a multisig needs 2 to %d signers, got %d", ErrInvalidPayload, MaxMultisigSigners, len(account.Signers))
+	}
+	if account.Threshold == 0 || account.Threshold > uint(len(account.Signers)){
+		return fmt.Errorf("%w: threshold must be between 1 and %d, got %d", ErrInvalidPayload, len(account.Signers), account.Threshold)
+	}
+
+	seen := make(map[common.Address]bool) 
+	for _, signer := range account.Signers{
+		if seen[signer]{
+			return fmt.Errorf("%w: signer '%s' is listed twice", ErrInvalidPayload, signer.String())
+		}
+		seen[signer] = true 
+	}
+
+	if tx.To != account.Address(){
+		return fmt.Errorf("%w: multisig address must be '%s' not '%s'", ErrInvalidPayload, account.Address().String(), tx.To.String())
+	}
+	if _, exists := s.Multisigs[tx.To]; exists{
+		return fmt.Errorf("%w: multisig '%s' is already registered", ErrInvalidPayload, tx.To.String())
+	}
+
+	return validateNoValue(tx)
+}
+
+func applyMultisigRegisterTx(tx Tx, s *State) error{
+	account := MultisigAccount{} 
+	err := tx.DecodePayload(&account) 
+	if err != nil{
+		return err 
+	}
+
+	s.Multisigs[tx.To] = NewMultisigAccount(account.Signers, account.Threshold) 
+
+	return nil 
+}
+
+// validateMultisigSigs verifies the tx carries signatures of at least threshold distinct signers
+func validateMultisigSigs(tx SignedTx, account MultisigAccount) error{
+	if len(tx.Sig) != 0{
+		return fmt.Errorf("%w: multisig '%s' txs must be signed by its signers", ErrInvalidSignature, tx.From.String())
+	}
+
+	txHash, err := tx.Tx.Hash() 
+	if err != nil{
+		return err 
+	}
+
+	signed := make(map[common.Address]bool) 
+	for _, sig := range tx.Sigs{
+		signer, err := recoverSigner(txHash, sig) 
+		if err != nil{
+			return fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error())
+		}
+		if !account.isSigner(signer){
+			return fmt.Errorf("%w: '%s' isn't a signer of multisig '%s'", ErrInvalidSignature, signer.String(), tx.From.String())
+		}
+		signed[signer] = true 
+	}
+
+	if uint(len(signed)) < account.Threshold{
+		return fmt.Errorf("%w: multisig '%s' requires %d signatures, got %d", ErrInvalidSignature, tx.From.String(), account.Threshold, len(signed))
+	}
+
+	return nil 
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestMultisigTx(t *testing.T){
+	keys := make([]*ecdsa.PrivateKey, 3) 
+	signers := make([]common.Address, 3) 
+	for i := range keys{
+		key, err := crypto.GenerateKey() 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		keys[i] = key 
+		signers[i] = crypto.PubkeyToAddress(key.PublicKey) 
+	}
+
+	s := newTestState(map[common.Address]uint{signers[0]: 100}) 
+
+	multisig := NewMultisigAccount(signers, 2) 
+	err := ApplyTx(signTestTx(t, NewMultisigRegisterTx(signers[0], signers, 2, 1), keys[0]), s) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	s.Balances[multisig.Address()] = 100 
+
+	tx := NewBaseTx(multisig.Address(), signers[0], 10, 1, "") 
+	sign := func(key *ecdsa.PrivateKey) []byte{
+		rawTx, err := tx.Encode() 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		txHash := sha256.Sum256(rawTx) 
+		sig, err := crypto.Sign(txHash[:], key) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		return sig 
+	}
+
+	outsider, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	tests := []struct{
+		name string 
+		tx SignedTx 
+		err error 
+	}{
+		{"threshold", NewMultisigTx(tx, [][]byte{sign(keys[0]), sign(keys[2])}), nil}, 
+		{"below threshold", NewMultisigTx(tx, [][]byte{sign(keys[1])}), ErrInvalidSignature}, 
+		{"same signer twice", NewMultisigTx(tx, [][]byte{sign(keys[1]), sign(keys[1])}), ErrInvalidSignature}, 
+		{"outsider", NewMultisigTx(tx, [][]byte{sign(keys[1]), sign(outsider)}), ErrInvalidSignature}, 
+		{"single sig", signTestTx(t, tx, keys[0]), ErrInvalidSignature}, 
+	}
+
+	for _, test := range tests{
+		err := ValidateTx(test.tx, s) 
+		if test.err == nil && err != nil{
+			t.Errorf("%s: expected no error, got %s", test.name, err) 
+		}
+		if test.err != nil && !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err) 
+		}
+	}
+
+	err = ValidateTx(signTestTx(t, NewMultisigRegisterTx(signers[0], signers, 2, 2), keys[0]), s) 
+	if !errors.Is(err, ErrInvalidPayload){
+		t.Errorf("expected registering the multisig twice to fail, got %v", err)
+	}
+}
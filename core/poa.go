@@ -0,0 +1,261 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const TxTypeValidatorVote = TxType("validator_vote")
+
+// PoAOutOfTurnDelay is how many seconds each validator waits after the previous one
+// in the turn order before sealing a block the in-turn validator missed
+const PoAOutOfTurnDelay = uint64(30)
+
+// PoAOutOfTurnMaxDrift is how many seconds ahead of the node clock an out of turn block
+// can be stamped, so its delay can't be met by stamping a future time
+const PoAOutOfTurnMaxDrift = uint64(5)
+
+// PoAEngine lets the validators take turns sealing the blocks with their signature.
+// The block at height n is sealed by the validator n modulo the size of the set,
+// or by the next ones in order once it is late by their out of turn delay.
+type PoAEngine struct{
+	mu sync.RWMutex
+	signerKey *ecdsa.PrivateKey
+}
+
+// ValidatorProposal to add or remove a validator, applied once voted by a majority of the validators
+type ValidatorProposal struct{
+	Validator common.Address `json:"validator"`
+	Add bool `json:"add"`
+}
+
+func init(){
+	registerTxKind(TxTypeValidatorVote, TxKind{
+		Validate: validateValidatorVoteTx,
+		Apply: applyValidatorVoteTx,
+	})
+}
+
+func NewPoAEngine() *PoAEngine{
+	return &PoAEngine{}
+}
+
+// Authorize makes the engine seal the blocks with the key of a validator
+func (e *PoAEngine) Authorize(key *ecdsa.PrivateKey){
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.signerKey = key
+}
+
+// Signer is the validator sealing the blocks, false until Authorize is called
+func (e *PoAEngine) Signer() (common.Address, bool){
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.signerKey == nil{
+		return common.Address{}, false
+	}
+	return crypto.PubkeyToAddress(e.signerKey.PublicKey), true
+}
+
+func (e *PoAEngine) Seal(ctx context.Context, b Block) (Block, error){
+	e.mu.RLock()
+	key := e.signerKey
+	e.mu.RUnlock()
+
+	if key == nil{
+		return Block{}, fmt.Errorf("%w: no validator key to seal blocks with", ErrNotValidator)
+	}
+
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	if b.Header.Miner != signer{
+		return Block{}, fmt.Errorf("%w: block miner '%s' isn't the signer '%s'", ErrBadSeal, b.Header.Miner.String(), signer.String())
+	}
+
+	sealHash, err := b.Header.SealHash()
+	if err != nil{
+		return Block{}, err
+	}
+
+	seal, err := crypto.Sign(sealHash[:], key)
+	if err != nil{
+		return Block{}, err
+	}
+	b.Header.Seal = seal
+
+	return b, nil
+}
+
+func (e *PoAEngine) VerifyHeader(h BlockHeader, hash Hash, parent BlockHeader, validators []common.Address) error{
+	if h.IsLegacy(){
+		return fmt.Errorf("%w: legacy header '%d' can't be sealed", ErrBadSeal, h.Number)
+	}
+
+	sealHash, err := h.SealHash()
+	if err != nil{
+		return err
+	}
+
+	signer, err := recoverSigner(sealHash, h.Seal)
+	if err != nil{
+		return fmt.Errorf("%w: header '%d'. %s", ErrBadSeal, h.Number, err.Error())
+	}
+	if signer != h.Miner{
+		return fmt.Errorf("%w: header '%d' is signed by '%s' not its miner '%s'", ErrBadSeal, h.Number, signer.String(), h.Miner.String())
+	}
+
+	delay, ok := e.SealDelay(signer, h.Number, validators)
+	if !ok{
+		return fmt.Errorf("%w: header '%d' is sealed by '%s' which isn't a validator", ErrBadSeal, h.Number, signer.String())
+	}
+	if h.Time < parent.Time+delay{
+		return fmt.Errorf("%w: header '%d' is sealed out of turn by '%s' before its %ds delay", ErrBadSeal, h.Number, signer.String(), delay)
+	}
+	if delay > 0 && h.Time > uint64(time.Now().Unix())+PoAOutOfTurnMaxDrift{
+		return fmt.Errorf("%w: out of turn header '%d' time '%d' is more than %ds ahead", ErrBlockFromFuture, h.Number, h.Time, PoAOutOfTurnMaxDrift)
+	}
+
+	return nil
+}
+
+// SealDelay is 0 for the in-turn validator and grows by PoAOutOfTurnDelay for each
+// validator following it in the turn order
+func (e *PoAEngine) SealDelay(account common.Address, number uint64, validators []common.Address) (uint64, bool){
+	count := uint64(len(validators))
+	for i, validator := range validators{
+		if validator == account{
+			inTurn := number % count
+			return (uint64(i) + count - inTurn) % count * PoAOutOfTurnDelay, true
+		}
+	}
+	return 0, false
+}
+
+// SealHash is the hash signed by the validators, the header hash without its seal
+func (h BlockHeader) SealHash() (Hash, error){
+	h.Seal = nil
+	return h.Hash()
+}
+
+// NewValidatorVoteTx votes for adding or removing the validator, only validators can vote
+func NewValidatorVoteTx(from, validator common.Address, add bool, nonce uint) Tx{
+	return NewTypedTx(from, validator, TxTypeValidatorVote, ValidatorProposal{validator, add}, 0, nonce)
+}
+
+func (s *State) IsValidator(account common.Address) bool{
+	for _, validator := range s.Validators{
+		if validator == account{
+			return true
+		}
+	}
+	return false
+}
+
+func validateValidatorVoteTx(tx Tx, s *State) error{
+	proposal := ValidatorProposal{}
+	err := tx.DecodePayload(&proposal)
+	if err != nil{
+		return err
+	}
+
+	if !s.IsValidator(tx.From){
+		return fmt.Errorf("%w: '%s' can't vote", ErrNotValidator, tx.From.String())
+	}
+	if proposal.Add && s.IsValidator(proposal.Validator){
+		return fmt.Errorf("%w: '%s' is already a validator", ErrInvalidPayload, proposal.Validator.String())
+	}
+	if !proposal.Add && !s.IsValidator(proposal.Validator){
+		return fmt.Errorf("%w: '%s' isn't a validator", ErrInvalidPayload, proposal.Validator.String())
+	}
+	if !proposal.Add && len(s.Validators) == 1{
+		return fmt.Errorf("%w: the last validator can't be removed", ErrInvalidPayload)
+	}
+	if s.ValidatorVotes[proposal][tx.From]{
+		return fmt.Errorf("%w: '%s' already voted for this proposal", ErrInvalidPayload, tx.From.String())
+	}
+
+	return validateNoValue(tx)
+}
+
+// applyValidatorVoteTx records the vote and changes the validator set once a majority agrees.
+// The votes cast by a removed validator are dropped.
+func applyValidatorVoteTx(tx Tx, s *State) error{
+	proposal := ValidatorProposal{}
+	err := tx.DecodePayload(&proposal)
+	if err != nil{
+		return err
+	}
+
+	if s.ValidatorVotes[proposal] == nil{
+		s.ValidatorVotes[proposal] = make(map[common.Address]bool)
+	}
+	s.ValidatorVotes[proposal][tx.From] = true
+
+	if len(s.ValidatorVotes[proposal]) <= len(s.Validators)/2{
+		return nil
+	}
+
+	delete(s.ValidatorVotes, proposal)
+
+	if proposal.Add{
+		s.Validators = sortAccounts(append(append([]common.Address(nil), s.Validators...), proposal.Validator))
+		return nil
+	}
+
+	validators := make([]common.Address, 0, len(s.Validators)-1)
+	for _, validator := range s.Validators{
+		if validator != proposal.Validator{
+			validators = append(validators, validator)
+		}
+	}
+	s.Validators = validators
+
+	for p, votes := range s.ValidatorVotes{
+		delete(votes, proposal.Validator)
+		if len(votes) == 0{
+			delete(s.ValidatorVotes, p)
+		}
+	}
+
+	return nil
+}
+
+// ValidatorsAfter returns the validator set once the headers are applied, following
+// the changes they announce
+func ValidatorsAfter(validators []common.Address, headers []BlockHeaderFS) []common.Address{
+	for _, h := range headers{
+		if len(h.Value.Validators) > 0{
+			validators = h.Value.Validators
+		}
+	}
+	return validators
+}
+
+func sameAccounts(a, b []common.Address) bool{
+	if len(a) != len(b){
+		return false
+	}
+	for i := range a{
+		if a[i] != b[i]{
+			return false
+		}
+	}
+	return true
+}
+
+func sortAccounts(accounts []common.Address) []common.Address{
+	sorted := append([]common.Address(nil), accounts...)
+	sort.Slice(sorted, func(i, j int) bool{
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	return sorted
+}
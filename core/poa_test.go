@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestValidators(t *testing.T, n int) ([]*ecdsa.PrivateKey, []common.Address){
+	keys := make([]*ecdsa.PrivateKey, n)
+	validators := make([]common.Address, n)
+	for i := range keys{
+		key, err := crypto.GenerateKey()
+		if err != nil{
+			t.Fatal(err)
+		}
+		keys[i] = key
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, validators
+}
+
+func TestPoAEngine(t *testing.T){
+	keys, validators := newTestValidators(t, 2)
+	engine := NewPoAEngine()
+
+	delay, ok := engine.SealDelay(validators[1], 1, validators)
+	if !ok || delay != 0{
+		t.Fatalf("expected '%s' to seal block 1 right away, got a %ds delay", validators[1].String(), delay)
+	}
+	delay, ok = engine.SealDelay(validators[0], 1, validators)
+	if !ok || delay != PoAOutOfTurnDelay{
+		t.Fatalf("expected '%s' to seal block 1 out of turn after %ds, got %ds", validators[0].String(), PoAOutOfTurnDelay, delay)
+	}
+
+	block := NewBlock(Hash{}, 1, 0, 100, validators[1], []SignedTx{NewCoinbaseTx(validators[1], 1, 100, BlockReward)})
+	parent := BlockHeader{Number: 0, Time: 90}
+
+	_, err := engine.Seal(context.Background(), block)
+	if !errors.Is(err, ErrNotValidator){
+		t.Errorf("expected %s sealing without a key, got %v", ErrNotValidator, err)
+	}
+
+	engine.Authorize(keys[1])
+	sealed, err := engine.Seal(context.Background(), block)
+	if err != nil{
+		t.Fatal(err)
+	}
+	hash, _ := sealed.Hash()
+
+	err = engine.VerifyHeader(sealed.Header, hash, parent, validators)
+	if err != nil{
+		t.Errorf("expected a valid seal, got %s", err)
+	}
+
+	// Out of turn, before and after the in-turn validator is late
+	err = engine.VerifyHeader(sealed.Header, hash, parent, []common.Address{validators[1], validators[0]})
+	if !errors.Is(err, ErrBadSeal){
+		t.Errorf("expected %s for an early out of turn seal, got %v", ErrBadSeal, err)
+	}
+	err = engine.VerifyHeader(sealed.Header, hash, BlockHeader{Time: 100 - PoAOutOfTurnDelay}, []common.Address{validators[1], validators[0]})
+	if err != nil{
+		t.Errorf("expected a valid out of turn seal once the in-turn validator is late, got %s", err)
+	}
+
+	// Stamping a future time doesn't make the delay pass sooner
+	future := uint64(time.Now().Unix()) + 600
+	early := NewBlock(Hash{}, 1, 0, future, validators[1], []SignedTx{NewCoinbaseTx(validators[1], 1, future, BlockReward)})
+	early, err = engine.Seal(context.Background(), early)
+	if err != nil{
+		t.Fatal(err)
+	}
+	earlyHash, _ := early.Hash()
+	err = engine.VerifyHeader(early.Header, earlyHash, BlockHeader{Time: future - PoAOutOfTurnDelay}, []common.Address{validators[1], validators[0]})
+	if !errors.Is(err, ErrBlockFromFuture){
+		t.Errorf("expected %s for an out of turn seal stamped in the future, got %v", ErrBlockFromFuture, err)
+	}
+	err = engine.VerifyHeader(early.Header, earlyHash, BlockHeader{Time: future - 1}, validators)
+	if err != nil{
+		t.Errorf("expected the in-turn validator to keep the usual drift, got %s", err)
+	}
+
+	err = engine.VerifyHeader(sealed.Header, hash, parent, validators[:1])
+	if !errors.Is(err, ErrBadSeal){
+		t.Errorf("expected %s for a seal of a non validator, got %v", ErrBadSeal, err)
+	}
+
+	forged := sealed
+	forged.Header.Miner = validators[0]
+	err = engine.VerifyHeader(forged.Header, hash, parent, validators)
+	if !errors.Is(err, ErrBadSeal){
+		t.Errorf("expected %s for a seal of another miner, got %v", ErrBadSeal, err)
+	}
+}
+
+func TestValidateHeaderChainValidatorChange(t *testing.T){
+	keys, validators := newTestValidators(t, 2)
+	validators = sortAccounts(validators)
+	keyOf := make(map[common.Address]*ecdsa.PrivateKey)
+	for _, key := range keys{
+		keyOf[crypto.PubkeyToAddress(key.PublicKey)] = key
+	}
+
+	// validators[0] seals alone until block 1 announces validators[1], which seals block 3 in turn
+	sealers := []common.Address{validators[0], validators[0], validators[0], validators[1]}
+	headers := make([]BlockHeaderFS, 0, len(sealers))
+	parent := Hash{}
+	for i, sealer := range sealers{
+		block := NewBlock(parent, uint64(i), 0, uint64(i+1), sealer, nil)
+		if i == 1{
+			block.Header.Validators = validators
+		}
+
+		engine := NewPoAEngine()
+		engine.Authorize(keyOf[sealer])
+		sealed, err := engine.Seal(context.Background(), block)
+		if err != nil{
+			t.Fatal(err)
+		}
+		hash, _ := sealed.Hash()
+
+		headers = append(headers, BlockHeaderFS{hash, sealed.Header})
+		parent = hash
+	}
+
+	err := ValidateHeaderChain(headers, BlockHeaderFS{}, false, NewPoAEngine(), validators[:1])
+	if err != nil{
+		t.Fatalf("expected the headers to follow the validator change, got %s", err)
+	}
+	if after := ValidatorsAfter(validators[:1], headers); !sameAccounts(after, validators){
+		t.Errorf("expected the announced validators, got %v", after)
+	}
+
+	err = ValidateHeaderChain(headers[2:], headers[1], true, NewPoAEngine(), validators[:1])
+	if !errors.Is(err, ErrBadSeal){
+		t.Errorf("expected %s without the header announcing the change, got %v", ErrBadSeal, err)
+	}
+}
+
+func TestValidatorVotes(t *testing.T){
+	keys, validators := newTestValidators(t, 3)
+	candidateKeys, candidates := newTestValidators(t, 1)
+
+	balances := map[common.Address]uint{candidates[0]: 10}
+	for _, validator := range validators{
+		balances[validator] = 10
+	}
+	s := newTestState(balances)
+	s.Validators = sortAccounts(validators)
+
+	err := ValidateTx(signTestTx(t, NewValidatorVoteTx(candidates[0], candidates[0], true, 1), candidateKeys[0]), s)
+	if !errors.Is(err, ErrNotValidator){
+		t.Errorf("expected %s for a vote of a non validator, got %v", ErrNotValidator, err)
+	}
+
+	err = ApplyTx(signTestTx(t, NewValidatorVoteTx(validators[0], candidates[0], true, 1), keys[0]), s)
+	if err != nil{
+		t.Fatal(err)
+	}
+	if s.IsValidator(candidates[0]){
+		t.Fatalf("expected no change before a majority voted")
+	}
+
+	err = ValidateTx(signTestTx(t, NewValidatorVoteTx(validators[0], candidates[0], true, 2), keys[0]), s)
+	if !errors.Is(err, ErrInvalidPayload){
+		t.Errorf("expected %s voting twice, got %v", ErrInvalidPayload, err)
+	}
+
+	err = ApplyTx(signTestTx(t, NewValidatorVoteTx(validators[1], candidates[0], true, 1), keys[1]), s)
+	if err != nil{
+		t.Fatal(err)
+	}
+	if !s.IsValidator(candidates[0]) || len(s.Validators) != 4{
+		t.Fatalf("expected '%s' to be added by the majority, got %v", candidates[0].String(), s.Validators)
+	}
+
+	for i := 0; i < 3; i++{
+		err = ApplyTx(signTestTx(t, NewValidatorVoteTx(validators[i], validators[2], false, s.GetNextAccountNonce(validators[i])), keys[i]), s)
+		if err != nil{
+			t.Fatal(err)
+		}
+	}
+	if s.IsValidator(validators[2]) || len(s.Validators) != 3{
+		t.Errorf("expected '%s' to be removed, got %v", validators[2].String(), s.Validators)
+	}
+}
+
+func TestValidateValidators(t *testing.T){
+	_, validators := newTestValidators(t, 2)
+	validators = sortAccounts(validators)
+
+	s := newTestState(map[common.Address]uint{})
+	s.Validators = validators
+
+	tests := []struct{
+		name string
+		before []common.Address
+		announced []common.Address
+		err error
+	}{
+		{"unchanged", validators, nil, nil},
+		{"announced", validators[:1], validators, nil},
+		{"not announced", validators[:1], nil, ErrBadValidators},
+		{"announced unchanged", validators, validators, ErrBadValidators},
+		{"wrong set", validators[:1], validators[1:], ErrBadValidators},
+	}
+
+	for _, test := range tests{
+		b := Block{Header: BlockHeader{Number: 1, Validators: test.announced}}
+		err := validateValidators(b, test.before, s)
+		if !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+		}
+	}
+}
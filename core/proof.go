@@ -0,0 +1,452 @@
+package core 
+
+import (
+	"bufio" 
+	"bytes" 
+	"crypto/sha256" 
+	"encoding/json" 
+	"fmt" 
+	"os" 
+	"sort" 
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MerkleProof lists the sibling hashes from a leaf up to the root
+type MerkleProof struct{
+	Index int `json:"index"`
+	Siblings []Hash `json:"siblings"`
+}
+
+// AccountState is a leaf of the state tree committed in the block header
+type AccountState struct{
+	Account common.Address `json:"account"`
+	Balance uint `json:"balance"`
+	Nonce uint `json:"nonce"`
+	Tokens map[string]uint `json:"tokens,omitempty"`
+	Multisig *MultisigAccount `json:"multisig,omitempty"`
+	Contract *ContractState `json:"contract,omitempty"`
+}
+
+// ContractState commits the contract code and storage in the account leaf
+type ContractState struct{
+	Creator common.Address `json:"creator"`
+	CodeHash Hash `json:"code_hash"`
+	StorageRoot Hash `json:"storage_root"`
+}
+
+// chainState is the state held by no account, committed next to the accounts in the state root
+type chainState struct{
+	Tokens map[string]Token `json:"tokens"`
+	Locks []Lock `json:"locks"`
+	Validators []common.Address `json:"validators"`
+	ValidatorVotes []validatorVotesState `json:"validator_votes"`
+	Finalized Checkpoint `json:"finalized"`
+	Checkpoints []Checkpoint `json:"checkpoints"`
+	CheckpointVotes []checkpointVotesState `json:"checkpoint_votes"`
+}
+
+type validatorVotesState struct{
+	Proposal ValidatorProposal `json:"proposal"`
+	Voters []common.Address `json:"voters"`
+}
+
+type checkpointVotesState struct{
+	Checkpoint Checkpoint `json:"checkpoint"`
+	Voters []common.Address `json:"voters"`
+}
+
+type AccountProof struct{
+	BlockHash Hash `json:"block_hash"`
+	State AccountState `json:"state"`
+	Proof MerkleProof `json:"proof"`
+}
+
+type TxProof struct{
+	BlockHash Hash `json:"block_hash"`
+	BlockNumber uint64 `json:"block_number"`
+	Tx SignedTx `json:"tx"`
+	Proof MerkleProof `json:"proof"`
+}
+
+func (p MerkleProof) Verify(root, leaf Hash) bool{
+	hash := leaf 
+	index := p.Index 
+
+	for _, sibling := range p.Siblings{
+		if index%2 == 0{
+			hash = hashPair(hash, sibling) 
+		} else{
+			hash = hashPair(sibling, hash) 
+		}
+		index /= 2 
+	}
+
+	return hash == root 
+}
+
+// VerifyAccountProof checks the account state is committed in the header state root
+func VerifyAccountProof(header BlockHeader, p AccountProof) error{
+	if header.IsLegacy(){
+		return fmt.Errorf("block '%d' has no state root", header.Number)
+	}
+
+	leaf, err := p.State.Leaf() 
+	if err != nil{
+		return err 
+	}
+
+	if !p.Proof.Verify(header.StateRoot, leaf){
+		return fmt.Errorf("invalid proof for account '%s' in block '%d'", p.State.Account.String(), header.Number)
+	}
+	return nil 
+}
+
+// VerifyTxProof checks the tx identified by txHash is committed in the header tx root
+func VerifyTxProof(header BlockHeader, txHash Hash, p TxProof) error{
+	if header.IsLegacy(){
+		return fmt.Errorf("block '%d' has no tx root", header.Number)
+	}
+
+	hash, err := p.Tx.Hash() 
+	if err != nil{
+		return err 
+	}
+	if hash != txHash{
+		return fmt.Errorf("proof is for tx '%x' not '%x'", hash, txHash)
+	}
+
+	leaf, err := p.Tx.Leaf() 
+	if err != nil{
+		return err 
+	}
+
+	if !p.Proof.Verify(header.TxRoot, leaf){
+		return fmt.Errorf("invalid proof for tx '%x' in block '%d'", txHash, header.Number)
+	}
+	return nil 
+}
+
+func (a AccountState) Leaf() (Hash, error){
+	accJson, err := json.Marshal(a) 
+	if err != nil{
+		return Hash{}, err 
+	}
+	return sha256.Sum256(accJson), nil 
+}
+
+func (t SignedTx) Leaf() (Hash, error){
+	txJson, err := json.Marshal(t) 
+	if err != nil{
+		return Hash{}, err 
+	}
+	return sha256.Sum256(txJson), nil 
+}
+
+// StateRoot hashes the merkle root of the accounts sorted by address with the chain state leaf. 
+// Account proofs end with the chain state leaf as their last sibling.
+func (s *State) StateRoot() (Hash, error){
+	leaves, _, err := s.accountLeaves() 
+	if err != nil{
+		return Hash{}, err 
+	}
+
+	chainLeaf, err := s.chainStateLeaf() 
+	if err != nil{
+		return Hash{}, err 
+	}
+
+	return hashPair(merkleRoot(leaves), chainLeaf), nil 
+}
+
+// StateRootAfter returns the state root resulting from applying the block txs and rewards.
+func (s *State) StateRootAfter(b Block) (Hash, error){
+	stateRoot, _, err := s.HeaderAfter(b) 
+	return stateRoot, err 
+}
+
+// HeaderAfter returns the state root and the validators announced by the block once its 
+// txs and rewards are applied. Used by miners to fill in the header before sealing it.
+func (s *State) HeaderAfter(b Block) (Hash, []common.Address, error){
+	pendingState := s.Copy() 
+
+	err := applyBlockTxs(b, &pendingState) 
+	if err != nil{
+		return Hash{}, nil, err 
+	}
+
+	stateRoot, err := pendingState.StateRoot() 
+	if err != nil{
+		return Hash{}, nil, err 
+	}
+
+	if sameAccounts(s.Validators, pendingState.Validators){
+		return stateRoot, nil, nil 
+	}
+	return stateRoot, pendingState.Validators, nil 
+}
+
+// AccountProof proves the account state against the state root of the latest block
+func (s *State) AccountProof(account common.Address) (AccountProof, error){
+	if s.latestBlock.Header.IsLegacy(){
+		return AccountProof{}, fmt.Errorf("block '%x' has no state root", s.latestBlockHash)
+	}
+
+	leaves, accounts, err := s.accountLeaves() 
+	if err != nil{
+		return AccountProof{}, err 
+	}
+
+	chainLeaf, err := s.chainStateLeaf() 
+	if err != nil{
+		return AccountProof{}, err 
+	}
+
+	for i, acc := range accounts{
+		if acc.Account == account{
+			proof := merkleProof(leaves, i) 
+			proof.Siblings = append(proof.Siblings, chainLeaf) 
+			return AccountProof{s.latestBlockHash, acc, proof}, nil 
+		}
+	}
+
+	return AccountProof{}, fmt.Errorf("%w: account '%s'", ErrNotFound, account.String())
+}
+
+func (s *State) accountLeaves() ([]Hash, []AccountState, error){
+	addresses := make(map[common.Address]bool, len(s.Balances)) 
+	for acc := range s.Balances{
+		addresses[acc] = true 
+	}
+	for acc := range s.AccountToNonce{
+		addresses[acc] = true 
+	}
+	for acc, tokens := range s.TokenBalances{
+		if len(tokens) > 0{
+			addresses[acc] = true 
+		}
+	}
+	for acc := range s.Multisigs{
+		addresses[acc] = true 
+	}
+	for acc := range s.Contracts{
+		addresses[acc] = true 
+	}
+
+	accounts := make([]AccountState, 0, len(addresses)) 
+	for acc := range addresses{
+		state := AccountState{Account: acc, Balance: s.Balances[acc], Nonce: s.AccountToNonce[acc], Tokens: s.TokenBalances[acc]} 
+
+		if multisig, ok := s.Multisigs[acc]; ok{
+			state.Multisig = &multisig 
+		}
+		if contract, ok := s.Contracts[acc]; ok{
+			state.Contract = &ContractState{contract.Creator, sha256.Sum256(contract.Code), contractStorageRoot(s.ContractStorage[acc])}
+		}
+
+		accounts = append(accounts, state) 
+	}
+
+	sort.Slice(accounts, func(i, j int) bool{
+		return bytes.Compare(accounts[i].Account[:], accounts[j].Account[:]) < 0 
+	})
+
+	leaves := make([]Hash, len(accounts)) 
+	for i, acc := range accounts{
+		leaf, err := acc.Leaf() 
+		if err != nil{
+			return nil, nil, err 
+		}
+		leaves[i] = leaf 
+	}
+
+	return leaves, accounts, nil 
+}
+
+// contractStorageRoot is the merkle root of the storage words sorted by key
+func contractStorageRoot(storage map[Hash]Hash) Hash{
+	keys := make([]Hash, 0, len(storage)) 
+	for key := range storage{
+		keys = append(keys, key) 
+	}
+	sort.Slice(keys, func(i, j int) bool{
+		return bytes.Compare(keys[i][:], keys[j][:]) < 0 
+	})
+
+	leaves := make([]Hash, len(keys)) 
+	for i, key := range keys{
+		leaves[i] = hashPair(key, storage[key]) 
+	}
+	return merkleRoot(leaves)
+}
+
+// chainStateLeaf hashes the tokens, locks, validators and checkpoints in a deterministic order
+func (s *State) chainStateLeaf() (Hash, error){
+	chain := chainState{
+		Tokens: s.Tokens, 
+		Locks: make([]Lock, 0, len(s.Locks)), 
+		Validators: s.Validators, 
+		ValidatorVotes: make([]validatorVotesState, 0, len(s.ValidatorVotes)), 
+		Finalized: s.finalized, 
+		Checkpoints: make([]Checkpoint, 0, len(s.checkpoints)), 
+		CheckpointVotes: make([]checkpointVotesState, 0, len(s.CheckpointVotes)), 
+	}
+
+	for _, lock := range s.Locks{
+		chain.Locks = append(chain.Locks, lock) 
+	}
+	sort.Slice(chain.Locks, func(i, j int) bool{
+		return bytes.Compare(chain.Locks[i].ID[:], chain.Locks[j].ID[:]) < 0 
+	})
+
+	for proposal, voters := range s.ValidatorVotes{
+		chain.ValidatorVotes = append(chain.ValidatorVotes, validatorVotesState{proposal, sortedVoters(voters)}) 
+	}
+	sort.Slice(chain.ValidatorVotes, func(i, j int) bool{
+		a, b := chain.ValidatorVotes[i].Proposal, chain.ValidatorVotes[j].Proposal 
+		if a.Validator != b.Validator{
+			return bytes.Compare(a.Validator[:], b.Validator[:]) < 0 
+		}
+		return !a.Add && b.Add 
+	})
+
+	for number, hash := range s.checkpoints{
+		chain.Checkpoints = append(chain.Checkpoints, Checkpoint{number, hash}) 
+	}
+	sort.Slice(chain.Checkpoints, func(i, j int) bool{
+		return chain.Checkpoints[i].Number < chain.Checkpoints[j].Number 
+	})
+
+	for checkpoint, voters := range s.CheckpointVotes{
+		chain.CheckpointVotes = append(chain.CheckpointVotes, checkpointVotesState{checkpoint, sortedVoters(voters)}) 
+	}
+	sort.Slice(chain.CheckpointVotes, func(i, j int) bool{
+		a, b := chain.CheckpointVotes[i].Checkpoint, chain.CheckpointVotes[j].Checkpoint 
+		if a.Number != b.Number{
+			return a.Number < b.Number 
+		}
+		return bytes.Compare(a.Hash[:], b.Hash[:]) < 0 
+	})
+
+	chainJson, err := json.Marshal(chain) 
+	if err != nil{
+		return Hash{}, err 
+	}
+	return sha256.Sum256(chainJson), nil 
+}
+
+func sortedVoters(voters map[common.Address]bool) []common.Address{
+	sorted := make([]common.Address, 0, len(voters)) 
+	for voter, voted := range voters{
+		if voted{
+			sorted = append(sorted, voter) 
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool{
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 
+	})
+	return sorted 
+}
+
+// GetTxProof looks the tx up in the blocks db and proves its inclusion against the block tx root
+func GetTxProof(txHash Hash, dataDir string) (TxProof, error){
+	f, err := os.OpenFile(getBlocksDbFilePath(dataDir), os.O_RDONLY, 0600)
+	if err != nil{
+		return TxProof{}, err 
+	}
+	defer f.Close() 
+
+	scanner := bufio.NewScanner(f) 
+	for scanner.Scan(){
+		var blockFs BlockFS 
+		err = json.Unmarshal(scanner.Bytes(), &blockFs) 
+		if err != nil{
+			return TxProof{}, err 
+		}
+
+		for i, tx := range blockFs.Value.Txs{
+			hash, err := tx.Hash() 
+			if err != nil{
+				return TxProof{}, err 
+			}
+			if hash != txHash{
+				continue
+			}
+
+			if blockFs.Value.Header.IsLegacy(){
+				return TxProof{}, fmt.Errorf("block '%x' has no tx root", blockFs.Key)
+			}
+
+			leaves, err := txLeaves(blockFs.Value.Txs) 
+			if err != nil{
+				return TxProof{}, err 
+			}
+
+			return TxProof{blockFs.Key, blockFs.Value.Header.Number, tx, merkleProof(leaves, i)}, nil
+		}
+	}
+	if err := scanner.Err(); err != nil{
+		return TxProof{}, err 
+	}
+
+	return TxProof{}, fmt.Errorf("%w: tx '%x'", ErrNotFound, txHash)
+}
+
+func txLeaves(txs []SignedTx) ([]Hash, error){
+	leaves := make([]Hash, len(txs)) 
+	for i, tx := range txs{
+		leaf, err := tx.Leaf() 
+		if err != nil{
+			return nil, err 
+		}
+		leaves[i] = leaf 
+	}
+	return leaves, nil 
+}
+
+// merkleRoot duplicates the last node of odd levels. 
+// The root of no leaves is the hash of nothing so it's never an empty hash.
+func merkleRoot(leaves []Hash) Hash{
+	if len(leaves) == 0{
+		return sha256.Sum256(nil)
+	}
+
+	level := leaves 
+	for len(level) > 1{
+		level = nextMerkleLevel(level) 
+	}
+	return level[0]
+}
+
+func merkleProof(leaves []Hash, index int) MerkleProof{
+	proof := MerkleProof{index, make([]Hash, 0)} 
+
+	level := leaves 
+	for len(level) > 1{
+		if len(level)%2 == 1{
+			level = append(level[:len(level):len(level)], level[len(level)-1]) 
+		}
+
+		proof.Siblings = append(proof.Siblings, level[index^1]) 
+		level = nextMerkleLevel(level) 
+		index /= 2 
+	}
+
+	return proof 
+}
+
+func nextMerkleLevel(level []Hash) []Hash{
+	if len(level)%2 == 1{
+		level = append(level[:len(level):len(level)], level[len(level)-1]) 
+	}
+
+	next := make([]Hash, len(level)/2) 
+	for i := range next{
+		next[i] = hashPair(level[2*i], level[2*i+1]) 
+	}
+	return next 
+}
+
+func hashPair(left, right Hash) Hash{
+	return sha256.Sum256(append(left[:], right[:]...))
+}
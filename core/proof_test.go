@@ -0,0 +1,109 @@
+package core
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMerkleProof(t *testing.T){
+	for count := 1; count <= 7; count++{
+		leaves := make([]Hash, count) 
+		for i := range leaves{
+			leaves[i] = sha256.Sum256([]byte{byte(i)}) 
+		}
+
+		root := merkleRoot(leaves) 
+		for i, leaf := range leaves{
+			proof := merkleProof(leaves, i) 
+			if !proof.Verify(root, leaf){
+				t.Errorf("expected proof of leaf %d out of %d to be valid", i, count) 
+			}
+
+			if proof.Verify(root, sha256.Sum256([]byte("forged"))){
+				t.Errorf("expected proof of a forged leaf to be invalid") 
+			}
+		}
+	}
+}
+
+func TestVerifyTxProof(t *testing.T){
+	txs := []SignedTx{
+		NewSignedTx(NewBaseTx(NewAccount("0x01"), NewAccount("0x02"), 10, 1, ""), []byte{1}), 
+		NewSignedTx(NewBaseTx(NewAccount("0x01"), NewAccount("0x03"), 20, 2, ""), []byte{2}), 
+		NewSignedTx(NewBaseTx(NewAccount("0x01"), NewAccount("0x04"), 30, 3, ""), []byte{3}), 
+	}
+	block := NewBlock(Hash{}, 0, 0, 0, NewAccount("0x01"), txs) 
+
+	leaves, err := txLeaves(txs) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	txHash, err := txs[2].Hash() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	proof := TxProof{Tx: txs[2], Proof: merkleProof(leaves, 2)} 
+	err = VerifyTxProof(block.Header, txHash, proof) 
+	if err != nil{
+		t.Fatalf("expected a valid tx proof, got %s", err) 
+	}
+
+	proof.Tx.Value++ 
+	err = VerifyTxProof(block.Header, txHash, proof) 
+	if err == nil{
+		t.Errorf("expected an error for a tampered tx") 
+	}
+}
+
+func TestStateRoot(t *testing.T){
+	alice, bob := NewAccount("0x01"), NewAccount("0x02") 
+	s := newTestState(map[common.Address]uint{alice: 100, bob: 50}) 
+	s.Contracts[bob] = Contract{bob, alice, []byte{byte(OpStop)}} 
+
+	root, err := s.StateRoot() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	s.latestBlock.Header = BlockHeader{TxRoot: Hash{1}, StateRoot: root} 
+	proof, err := s.AccountProof(bob) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	err = VerifyAccountProof(s.latestBlock.Header, proof) 
+	if err != nil{
+		t.Fatalf("expected a valid account proof, got %s", err) 
+	}
+	if proof.State.Contract == nil{
+		t.Errorf("expected the contract committed in its account leaf") 
+	}
+
+	changes := []struct{
+		name string 
+		change func() 
+	}{
+		{"contract storage", func(){ s.ContractStorage[bob] = map[Hash]Hash{{}: WordFromUint(1)} }}, 
+		{"multisig", func(){ s.Multisigs[alice] = MultisigAccount{[]common.Address{alice, bob}, 2} }}, 
+		{"lock", func(){ s.Locks[Hash{2}] = Lock{ID: Hash{2}, From: alice, To: bob, Value: 10} }}, 
+		{"validators", func(){ s.Validators = []common.Address{alice} }}, 
+		{"checkpoint votes", func(){ s.CheckpointVotes[Checkpoint{4, Hash{3}}] = map[common.Address]bool{alice: true} }}, 
+		{"finalized", func(){ s.finalized = Checkpoint{4, Hash{3}} }}, 
+	}
+
+	for _, test := range changes{
+		test.change() 
+
+		next, err := s.StateRoot() 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		if next == root{
+			t.Errorf("%s: expected the change to update the state root", test.name) 
+		}
+		root = next 
+	}
+}
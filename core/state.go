@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
 	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -13,7 +12,11 @@ import (
 
 const TxGas = 1
 const TxGasPriceDefault = 1
-const TxFee = uint(50)
+
+// A block time must exceed the median time of the last BlockTimeMedianWindow blocks 
+// and can't be more than the drift (in seconds) ahead of the node clock
+const BlockTimeMedianWindow = 11
+const DefaultMaxBlockTimeDrift = uint64(15 * 60)
 
 type State struct {
 	Balances map[common.Address]uint 
@@ -25,10 +28,41 @@ type State struct {
 	latestBlockHash Hash 
 	hasGenesisBlock bool 
 
-	miningDifficulty uint 
+	engine Engine 
 
 	HashCache map[string]int64 
 	HeightCache map[uint64]int64
+
+	recentBlockTimes []uint64 
+	maxBlockTimeDrift uint64 
+
+	emission EmissionSchedule 
+	genesisSupply uint 
+	totalMinted uint 
+	totalBurned uint 
+
+	Tokens map[string]Token 
+	TokenBalances map[common.Address]map[string]uint 
+
+	Multisigs map[common.Address]MultisigAccount 
+
+	Locks map[Hash]Lock 
+
+	Contracts map[common.Address]Contract 
+	ContractStorage map[common.Address]map[Hash]Hash 
+
+	// Proof of authority validators, sorted, and the pending votes changing them
+	Validators []common.Address 
+	ValidatorVotes map[ValidatorProposal]map[common.Address]bool 
+
+	finality FinalityConfig 
+	// Last checkpoint signed by the validators, the unsigned checkpoints and their signatures
+	finalized Checkpoint 
+	checkpoints map[uint64]Hash 
+	CheckpointVotes map[Checkpoint]map[common.Address]bool 
+
+	// Index of the anchored hashes, only kept by the state following the chain
+	anchors map[Hash]Anchor 
 }
 
 func NewStateFromDisk(dataDir string, miningDifficulty uint) (*State, error){
@@ -37,40 +71,32 @@ func NewStateFromDisk(dataDir string, miningDifficulty uint) (*State, error){
 		return nil, err 
 	}
 
-	gen, err := loadGenesis(getGenesisJsonFilePath(dataDir)) 
-	if err != nil {
+	state, err := newGenesisState(dataDir, miningDifficulty) 
+	if err != nil{
 		return nil, err 
 	}
 
-	balances := make(map[common.Address]uint)
-	for account, balance := range gen.Balances{
-		balances[account] = balance
-	}
-	
-	accountToNonce := make(map[common.Address]uint) 
-
 	dbFilepath := getBlocksDbFilePath(dataDir)
 	f, err := os.OpenFile(dbFilepath, os.O_APPEND|os.O_RDWR, 0600) 
 	if err != nil{
 		return nil, err 
 	}
 
-	scanner := bufio.NewScanner(f)
-
-	state := &State{
-		balances, 
-		accountToNonce, 
-		f, 
-		Block{}, 
-		Hash{}, 
-		false, 
-		miningDifficulty, 
-		map[string]int64{}, 
-		map[uint64]int64{},
+	dropped, err := truncatePartialRecord(f) 
+	if err != nil{
+		return nil, err 
 	}
+	if dropped > 0{
+		stateLog().Warn("dropped the partially written last block", "file", dbFilepath, "bytes", dropped) 
+	}
+
+	state.dbFile = f 
+
+	scanner := bufio.NewScanner(f)
 
 	// File position 
 	filePos := int64(0) 
+	line := 0 
 
 	for scanner.Scan(){
 		if err := scanner.Err(); err != nil{
@@ -78,34 +104,125 @@ func NewStateFromDisk(dataDir string, miningDifficulty uint) (*State, error){
 		}
 
 		blockFsJson := scanner.Bytes() 
+		line++ 
 
 		if len(blockFsJson) == 0{
 			break
 		}
 
-		var blockFs BlockFS 
-		err = json.Unmarshal(blockFsJson, &blockFs)
+		err = state.loadBlock(blockFsJson, filePos) 
 		if err != nil{
-			return nil, err 
+			return nil, fmt.Errorf("invalid record at line %d of %s. %w", line, dbFilepath, err) 
 		}
+		filePos += int64(len(blockFsJson)) + 1 
+	}
 
-		err = applyBlock(blockFs.Value, state)
-		if err != nil{
-			return nil, err 
-		}
+	if state.hasGenesisBlock{
+		observeHead(state.latestBlock) 
+	}
 
-		// Set search caches 
-		state.HashCache[blockFs.Key.Hex()] = filePos 
-		state.HeightCache[blockFs.Value.Header.Number] = filePos 
-		filePos += int64(len(blockFsJson)) + 1 
+	return state, nil 
+}
 
-		state.latestBlock = blockFs.Value 
-		state.latestBlockHash = blockFs.Key 
-		state.hasGenesisBlock = true 
+// newGenesisState is the state before the first block, without a db file 
+func newGenesisState(dataDir string, miningDifficulty uint) (*State, error){
+	gen, err := loadGenesis(getGenesisJsonFilePath(dataDir)) 
+	if err != nil {
+		return nil, err 
 	}
+
+	consensus := gen.ConsensusConfig() 
+	engine, err := NewEngine(consensus, miningDifficulty) 
+	if err != nil{
+		return nil, err 
+	}
+
+	var validators []common.Address 
+	if consensus.Engine == ConsensusPoA{
+		validators = sortAccounts(consensus.Validators) 
+	}
+
+	balances := make(map[common.Address]uint)
+	genesisSupply := uint(0) 
+	for account, balance := range gen.Balances{
+		balances[account] = balance
+		genesisSupply += balance 
+	}
+	
+	accountToNonce := make(map[common.Address]uint) 
+
+	state := &State{
+		Balances: balances, 
+		AccountToNonce: accountToNonce, 
+		latestBlock: Block{}, 
+		latestBlockHash: Hash{}, 
+		hasGenesisBlock: false, 
+		engine: engine, 
+		HashCache: map[string]int64{}, 
+		HeightCache: map[uint64]int64{},
+		recentBlockTimes: make([]uint64, 0), 
+		maxBlockTimeDrift: DefaultMaxBlockTimeDrift, 
+		emission: gen.EmissionSchedule(), 
+		genesisSupply: genesisSupply, 
+		Tokens: make(map[string]Token), 
+		TokenBalances: make(map[common.Address]map[string]uint), 
+		Multisigs: make(map[common.Address]MultisigAccount), 
+		Locks: make(map[Hash]Lock), 
+		Contracts: make(map[common.Address]Contract), 
+		ContractStorage: make(map[common.Address]map[Hash]Hash), 
+		Validators: validators, 
+		ValidatorVotes: make(map[ValidatorProposal]map[common.Address]bool), 
+		finality: gen.FinalityConfig(), 
+		checkpoints: make(map[uint64]Hash), 
+		CheckpointVotes: make(map[Checkpoint]map[common.Address]bool), 
+		anchors: make(map[Hash]Anchor), 
+	}
+
 	return state, nil 
 }
 
+// loadBlock verifies and applies a record of the block db found at filePos 
+func (s *State) loadBlock(blockFsJson []byte, filePos int64) error{
+	var blockFs BlockFS 
+	err := json.Unmarshal(blockFsJson, &blockFs)
+	if err != nil{
+		return err 
+	}
+
+	hash, err := blockFs.Value.Hash() 
+	if err != nil{
+		return err 
+	}
+	if hash != blockFs.Key{
+		return fmt.Errorf("%w: block '%d' is stored as '%x' but hashes to '%x'", ErrBadBlockHash, blockFs.Value.Header.Number, blockFs.Key, hash) 
+	}
+
+	// The following blocks are checked against their parent by applyBlock 
+	if !s.hasGenesisBlock && blockFs.Value.Header.Number != 0{
+		return fmt.Errorf("%w: first block number must be '0' not '%d'", ErrBadBlockNumber, blockFs.Value.Header.Number) 
+	}
+
+	err = applyBlock(blockFs.Value, s)
+	if err != nil{
+		return err 
+	}
+
+	err = s.indexAnchors(blockFs.Value, blockFs.Key) 
+	if err != nil{
+		return err 
+	}
+
+	// Set search caches 
+	s.HashCache[blockFs.Key.Hex()] = filePos 
+	s.HeightCache[blockFs.Value.Header.Number] = filePos 
+
+	s.latestBlock = blockFs.Value 
+	s.latestBlockHash = blockFs.Key 
+	s.hasGenesisBlock = true 
+
+	return nil 
+}
+
 func (s *State) AddBlocks(blocks []Block) error{
 	for _, b := range blocks{
 		_, err := s.AddBlock(b)
@@ -119,7 +236,13 @@ func (s *State) AddBlocks(blocks []Block) error{
 func (s *State) AddBlock(b Block) (Hash, error){
 	pendingState := s.Copy() 
 
-	err := applyBlock(b, &pendingState) 
+	// Only checked for new blocks, the ones on disk were valid when received
+	err := validateBlockTimeDrift(b, s.maxBlockTimeDrift) 
+	if err != nil{
+		return Hash{}, err 
+	}
+
+	err = applyBlock(b, &pendingState) 
 	if err != nil{
 		return Hash{}, err 
 	}
@@ -135,13 +258,12 @@ func (s *State) AddBlock(b Block) (Hash, error){
 		return Hash{}, err 
 	}
 
-	fmt.Printf("\nPerssisting new block to disk:\n") 
-	fmt.Printf("\t%s\n", blockFsJson) 
+	stateLog().Debug("persisting new block", "hash", blockHash.Hex(), "height", b.Header.Number, "txs", len(b.Txs)) 
 
 	fs, _ := s.dbFile.Stat() 
-	filePos := fs.Size() + 1 
+	filePos := fs.Size() 
 
-	_, err = s.dbFile.Write(append(blockFsJson, '\n')) 
+	err = appendRecord(s.dbFile, blockFsJson) 
 	if err != nil{
 		return Hash{}, err 
 	}
@@ -150,12 +272,32 @@ func (s *State) AddBlock(b Block) (Hash, error){
 	s.HashCache[blockFs.Key.Hex()] = filePos 
 	s.HeightCache[blockFs.Value.Header.Number] = filePos 
 
+	observeNewBlock(b, s.latestBlock.Header.Time, s.hasGenesisBlock) 
+
 	s.Balances = pendingState.Balances 
 	s.AccountToNonce = pendingState.AccountToNonce
 	s.latestBlockHash = blockHash 
 	s.latestBlock = b 
 	s.hasGenesisBlock = true 
-	s.miningDifficulty = pendingState.miningDifficulty
+	s.recentBlockTimes = pendingState.recentBlockTimes
+	s.totalMinted = pendingState.totalMinted
+	s.totalBurned = pendingState.totalBurned
+	s.Tokens = pendingState.Tokens
+	s.TokenBalances = pendingState.TokenBalances
+	s.Multisigs = pendingState.Multisigs
+	s.Locks = pendingState.Locks
+	s.Contracts = pendingState.Contracts
+	s.ContractStorage = pendingState.ContractStorage
+	s.Validators = pendingState.Validators
+	s.ValidatorVotes = pendingState.ValidatorVotes
+	s.finalized = pendingState.finalized
+	s.checkpoints = pendingState.checkpoints
+	s.CheckpointVotes = pendingState.CheckpointVotes
+
+	err = s.indexAnchors(b, blockHash) 
+	if err != nil{
+		return Hash{}, err 
+	}
 
 	return blockHash, nil 
 }
@@ -180,8 +322,37 @@ func (s *State) GetNextAccountNonce(account common.Address) uint{
 	return s.AccountToNonce[account] + 1 
 }
 
+// ChangeMiningDifficulty only applies to proof of work chains
 func (s *State) ChangeMiningDifficulty(newDifficulty uint){
-	s.miningDifficulty = newDifficulty
+	if _, isPoW := s.engine.(*PoWEngine); isPoW{
+		s.engine = NewPoWEngine(newDifficulty)
+	}
+}
+
+// Engine sealing and verifying the blocks of the chain
+func (s *State) Engine() Engine{
+	return s.engine
+}
+
+func (s *State) ChangeMaxBlockTimeDrift(seconds uint64){
+	s.maxBlockTimeDrift = seconds
+}
+
+// MinNextBlockTime is the earliest time the next block can be stamped with
+func (s *State) MinNextBlockTime() uint64{
+	if len(s.recentBlockTimes) == 0{
+		return 0 
+	}
+	return s.medianBlockTime() + 1 
+}
+
+func (s *State) medianBlockTime() uint64{
+	times := append([]uint64(nil), s.recentBlockTimes...) 
+	sort.Slice(times, func(i, j int) bool{
+		return times[i] < times[j]
+	})
+
+	return times[len(times)/2]
 }
 
 func (s *State) Copy() State{
@@ -191,7 +362,25 @@ func (s *State) Copy() State{
 	c.latestBlockHash = s.latestBlockHash 
 	c.Balances = make(map[common.Address]uint) 
 	c.AccountToNonce = make(map[common.Address]uint) 
-	c.miningDifficulty = s.miningDifficulty 
+	c.engine = s.engine 
+	c.recentBlockTimes = append([]uint64(nil), s.recentBlockTimes...) 
+	c.maxBlockTimeDrift = s.maxBlockTimeDrift 
+	c.emission = s.emission 
+	c.genesisSupply = s.genesisSupply 
+	c.totalMinted = s.totalMinted 
+	c.totalBurned = s.totalBurned 
+	c.Tokens = make(map[string]Token) 
+	c.TokenBalances = make(map[common.Address]map[string]uint) 
+	c.Multisigs = make(map[common.Address]MultisigAccount) 
+	c.Locks = make(map[Hash]Lock) 
+	c.Contracts = make(map[common.Address]Contract) 
+	c.ContractStorage = make(map[common.Address]map[Hash]Hash) 
+	c.Validators = append([]common.Address(nil), s.Validators...) 
+	c.ValidatorVotes = make(map[ValidatorProposal]map[common.Address]bool) 
+	c.finality = s.finality 
+	c.finalized = s.finalized 
+	c.checkpoints = make(map[uint64]Hash) 
+	c.CheckpointVotes = make(map[Checkpoint]map[common.Address]bool) 
 	
 	
 	for acc, balance := range s.Balances{
@@ -202,6 +391,54 @@ func (s *State) Copy() State{
 		c.AccountToNonce[acc] = nonce 
 	}
 
+	for symbol, token := range s.Tokens{
+		c.Tokens[symbol] = token 
+	}
+
+	for acc, multisig := range s.Multisigs{
+		c.Multisigs[acc] = multisig 
+	}
+
+	for id, lock := range s.Locks{
+		c.Locks[id] = lock 
+	}
+
+	for addr, contract := range s.Contracts{
+		c.Contracts[addr] = contract 
+	}
+
+	for addr, storage := range s.ContractStorage{
+		c.ContractStorage[addr] = make(map[Hash]Hash) 
+		for key, value := range storage{
+			c.ContractStorage[addr][key] = value
+		}
+	}
+
+	for proposal, votes := range s.ValidatorVotes{
+		c.ValidatorVotes[proposal] = make(map[common.Address]bool) 
+		for voter := range votes{
+			c.ValidatorVotes[proposal][voter] = true
+		}
+	}
+
+	for number, hash := range s.checkpoints{
+		c.checkpoints[number] = hash 
+	}
+
+	for checkpoint, votes := range s.CheckpointVotes{
+		c.CheckpointVotes[checkpoint] = make(map[common.Address]bool) 
+		for voter := range votes{
+			c.CheckpointVotes[checkpoint][voter] = true
+		}
+	}
+
+	for acc, balances := range s.TokenBalances{
+		c.TokenBalances[acc] = make(map[string]uint) 
+		for symbol, balance := range balances{
+			c.TokenBalances[acc][symbol] = balance
+		}
+	}
+
 	return c 
 }
 
@@ -212,45 +449,88 @@ func (s *State) Close() error{
 // applyBlock verifies whether this block can be added to the blockchain 
 // block meta data are verified as well as transactions within (are blanaces sufficient, etc) 
 func applyBlock(b Block, s *State) error{
-	nextExpectedBlockNumber := s.latestBlock.Header.Number + 1 
-
-	if s.hasGenesisBlock && b.Header.Number != nextExpectedBlockNumber{
-		return fmt.Errorf("next expected block number must be '%d' not '%d'", nextExpectedBlockNumber, b.Header.Number) 
+	err := validateBlock(b, s) 
+	if err != nil{
+		return err 
+	}
 
+	validators := s.Validators 
+	err = applyBlockTxs(b, s) 
+	if err != nil{
+		return err
+	}
 
+	err = validateValidators(b, validators, s) 
+	if err != nil{
+		return err 
 	}
 
-	if s.hasGenesisBlock && s.latestBlock.Header.Number > 0 && !reflect.DeepEqual(b.Header.Parent, s.latestBlockHash){
-		return fmt.Errorf("next block parent hash must be '%x' not '%x'", s.latestBlockHash, b.Header.Parent)
+	err = validateStateRoot(b, s) 
+	if err != nil{
+		return err 
 	}
 
-	hash, err := b.Hash()
+	hash, err := b.Hash() 
 	if err != nil{
-		return err
+		return err 
 	}
+	s.recordCheckpoint(b, hash) 
 
-	if !IsBlockHashValid(hash, s.miningDifficulty){
-		return fmt.Errorf("invalid block hash %x", hash) 
+	s.recentBlockTimes = append(s.recentBlockTimes, b.Header.Time) 
+	if len(s.recentBlockTimes) > BlockTimeMedianWindow{
+		s.recentBlockTimes = s.recentBlockTimes[1:]
 	}
 
-	err = applyTxs(b.Txs, s)  
+	return nil 
+}
+
+// applyBlockTxs applies the block txs and credits the miner rewards. 
+// Legacy blocks have no coinbase tx, their miner is credited implicitly.
+func applyBlockTxs(b Block, s *State) error{
+	reward := s.BlockRewardAt(b.Header.Number) 
+
+	if b.Header.IsLegacy(){
+		err := applyTxs(b.Txs, s, 0, true)  
+		if err != nil{
+			return err
+		}
+
+		s.Balances[b.Header.Miner] += reward 
+		for _, tx := range b.Txs{
+			s.Balances[b.Header.Miner] += tx.legacyGasCost() 
+		}
+		s.totalMinted += reward 
+
+		return nil 
+	}
+
+	coinbase, ok := b.Coinbase() 
+	if !ok{
+		return fmt.Errorf("%w: block '%d' first tx must be the coinbase", ErrBadCoinbase, b.Header.Number)
+	}
+
+	err := applyTxs(b.Txs[1:], s, b.Header.BaseFee, false) 
 	if err != nil{
 		return err
 	}
 
-	s.Balances[b.Header.Miner] += BlockReward 
-	s.Balances[b.Header.Miner] += b.GasReward()
+	s.Balances[coinbase.To] += coinbase.Value 
+	s.totalMinted += reward 
 
 	return nil 
 }
 
-func applyTxs(txs []SignedTx, s *State) error{
+// applyTxs applies the txs at the block base fee. Legacy blocks have none 
+// and charge the legacy gas cost instead.
+func applyTxs(txs []SignedTx, s *State, baseFee uint, legacy bool) error{
+	// Sort a copy, the block txs order is covered by its hash
+	txs = append([]SignedTx(nil), txs...) 
 	sort.Slice(txs, func(i, j int) bool{
 		return txs[i].Time < txs[j].Time
 	})
 
 	for _, tx := range txs{
-		err := ApplyTx(tx, s)  
+		err := applyTx(tx, s, baseFee, legacy)  
 		if err != nil{
 			return err 
 		}
@@ -259,44 +539,38 @@ func applyTxs(txs []SignedTx, s *State) error{
 	return nil 
 }
 
+// ApplyTx applies the tx as part of the next block
 func ApplyTx(tx SignedTx, s *State) error{
-	err := ValidateTx(tx, s) 
+	return applyTx(tx, s, s.NextBaseFee(), false)
+}
+
+func applyTx(tx SignedTx, s *State, baseFee uint, legacy bool) error{
+	err := validateTx(tx, s, baseFee, legacy) 
 	if err != nil{
 		return err 
 	}
 
-	s.Balances[tx.From] -= tx.Cost() 
-	s.Balances[tx.To] += tx.Value 
-
-	s.AccountToNonce[tx.From] = tx.Nonce 
-
-	return nil 
-}
-
-func ValidateTx(tx SignedTx, s *State) error{
-	ok, err := tx.IsAuthentic() 
+	kind, err := txKindOf(tx.Tx) 
 	if err != nil{
 		return err 
 	}
 
-	if !ok{
-		return fmt.Errorf("wrong TX. Sender is '%s' is forged", tx.From.String())
+	// Kinds only change the state once they succeed, e.g. contract calls, 
+	// so a failing tx leaves it untouched
+	err = kind.Apply(tx.Tx, s) 
+	if err != nil{
+		return err 
 	}
 
-	expectedNonce := s.GetNextAccountNonce(tx.From) 
-	if tx.Nonce != expectedNonce{
-		return fmt.Errorf("wrong Tx. Sender '%s' next nonce must be '%d', not '%d'", tx.From.String(), expectedNonce, tx.Nonce)
+	burn, tip := tx.Fee(baseFee) 
+	if legacy{
+		burn, tip = 0, tx.legacyGasCost()
 	}
 
-	if tx.Gas != TxGas{
-		return fmt.Errorf("insufficient Tx Gas %v. required: %v", tx.Gas, TxGas) 
-	}
-	if tx.GasPrice < TxGasPriceDefault{
-		return fmt.Errorf("insufficient Tx gasPrice %v. required at least: %v", tx.GasPrice, TxGasPriceDefault)
-	}
+	s.Balances[tx.From] -= burn + tip 
+	s.totalBurned += burn 
+
+	s.AccountToNonce[tx.From] = tx.Nonce 
 
-	if tx.Cost() > s.Balances[tx.From]{
-		return fmt.Errorf("wrong TX. Sender '%s' balance is %d NEM. Tx cost is %d NEM", tx.From.String(), s.Balances[tx.From], tx.Cost())
-	}
 	return nil 
-}
\ No newline at end of file
+}
@@ -1 +1,78 @@
-package core
\ No newline at end of file
+package core
+
+import (
+	"encoding/json" 
+	"fmt" 
+	"os" 
+	"strings" 
+	"testing" 
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A data dir written before gas was priced per unit must replay to the same balances
+func TestReplayLegacyBlockDB(t *testing.T){
+	privKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	from, to, miner := crypto.PubkeyToAddress(privKey.PublicKey), NewAccount("0x02"), NewAccount("0x03") 
+
+	dataDir := t.TempDir() 
+	genesis := fmt.Sprintf(`{"symbol": "NEM", "balances": {"%s": 1000}}`, from.Hex()) 
+	err = InitDataDirIfNotExists(dataDir, []byte(genesis)) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	// Txs had no type before the typed txs
+	tx := Tx{From: from, To: to, Gas: TxGas, GasPrice: 2, Value: 100, Nonce: 1, Time: 1700000000} 
+	block := NewBlock(Hash{}, 0, 0, 1700000010, miner, []SignedTx{signTestTx(t, tx, privKey)}) 
+	block.Header.TxRoot = Hash{} 
+	block = mineTestBlock(block) 
+
+	hash, err := block.Hash() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	record, err := json.Marshal(BlockFS{hash, block}) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	for _, field := range []string{"tx_root", "base_fee", "max_fee", "type"}{
+		if strings.Contains(string(record), `"` + field + `"`){
+			t.Fatalf("expected a record in the baseline format, got %s", record) 
+		}
+	}
+
+	err = os.WriteFile(getBlocksDbFilePath(dataDir), append(record, '\n'), 0600) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	state, err := NewStateFromDisk(dataDir, 0) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	defer state.Close() 
+
+	// Legacy blocks charged gas + gas price, not gas * gas price
+	gasCost := uint(TxGas + 2) 
+	expected := map[string]uint{
+		"sender": 1000 - 100 - gasCost, 
+		"recipient": 100, 
+		"miner": BlockReward + gasCost, 
+	}
+	balances := map[string]uint{
+		"sender": state.Balances[from], 
+		"recipient": state.Balances[to], 
+		"miner": state.Balances[miner], 
+	}
+
+	for account, balance := range expected{
+		if balances[account] != balance{
+			t.Errorf("expected the %s balance to be %d, got %d", account, balance, balances[account]) 
+		}
+	}
+}
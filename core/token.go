@@ -0,0 +1,142 @@
+package core 
+
+import (
+	"fmt" 
+	"regexp" 
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const TxTypeTokenCreate = TxType("token_create")
+const TxTypeTokenTransfer = TxType("token_transfer")
+
+const TokenMaxDecimals = 18
+
+var tokenSymbolRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,7}$`)
+
+type Token struct{
+	Symbol string `json:"symbol"`
+	Decimals uint8 `json:"decimals"`
+	Supply uint `json:"supply"`
+	Creator common.Address `json:"creator"`
+}
+
+// TokenCreatePayload mints the whole token supply to the tx sender
+type TokenCreatePayload struct{
+	Symbol string `json:"symbol"`
+	Decimals uint8 `json:"decimals"`
+	Supply uint `json:"supply"`
+}
+
+// TokenTransferPayload moves token units from the tx sender to the tx recipient
+type TokenTransferPayload struct{
+	Symbol string `json:"symbol"`
+	Amount uint `json:"amount"`
+}
+
+func init(){
+	registerTxKind(TxTypeTokenCreate, TxKind{
+		Validate: validateTokenCreateTx, 
+		Apply: applyTokenCreateTx, 
+	})
+	registerTxKind(TxTypeTokenTransfer, TxKind{
+		Validate: validateTokenTransferTx, 
+		Apply: applyTokenTransferTx, 
+	})
+}
+
+func NewTokenCreateTx(from common.Address, symbol string, decimals uint8, supply uint, nonce uint) Tx{
+	return NewTypedTx(from, from, TxTypeTokenCreate, TokenCreatePayload{symbol, decimals, supply}, 0, nonce)
+}
+
+func NewTokenTransferTx(from, to common.Address, symbol string, amount uint, nonce uint) Tx{
+	return NewTypedTx(from, to, TxTypeTokenTransfer, TokenTransferPayload{symbol, amount}, 0, nonce)
+}
+
+func validateTokenCreateTx(tx Tx, s *State) error{
+	payload := TokenCreatePayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	if !tokenSymbolRegexp.MatchString(payload.Symbol){
+		return fmt.Errorf("%w: symbol '%s' must be 2 to 8 upper case letters or digits", ErrInvalidToken, payload.Symbol)
+	}
+	if payload.Decimals > TokenMaxDecimals{
+		return fmt.Errorf("%w: decimals '%d' must be at most %d", ErrInvalidToken, payload.Decimals, TokenMaxDecimals)
+	}
+	if _, exists := s.Tokens[payload.Symbol]; exists{
+		return fmt.Errorf("%w: token '%s' already exists", ErrInvalidToken, payload.Symbol)
+	}
+	if payload.Supply == 0{
+		return fmt.Errorf("%w: token '%s' supply can't be zero", ErrInvalidToken, payload.Symbol)
+	}
+
+	return validateNoValue(tx)
+}
+
+func applyTokenCreateTx(tx Tx, s *State) error{
+	payload := TokenCreatePayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	s.Tokens[payload.Symbol] = Token{payload.Symbol, payload.Decimals, payload.Supply, tx.From}
+	s.addTokenBalance(tx.From, payload.Symbol, payload.Supply) 
+
+	return nil 
+}
+
+func validateTokenTransferTx(tx Tx, s *State) error{
+	payload := TokenTransferPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	if _, exists := s.Tokens[payload.Symbol]; !exists{
+		return fmt.Errorf("%w: token '%s'", ErrNotFound, payload.Symbol)
+	}
+	if payload.Amount > s.TokenBalances[tx.From][payload.Symbol]{
+		return fmt.Errorf("%w: sender '%s' holds %d %s. Tx transfers %d", ErrInsufficientBalance, tx.From.String(), s.TokenBalances[tx.From][payload.Symbol], payload.Symbol, payload.Amount)
+	}
+
+	return validateNoValue(tx)
+}
+
+func applyTokenTransferTx(tx Tx, s *State) error{
+	payload := TokenTransferPayload{} 
+	err := tx.DecodePayload(&payload) 
+	if err != nil{
+		return err 
+	}
+
+	s.TokenBalances[tx.From][payload.Symbol] -= payload.Amount 
+	if s.TokenBalances[tx.From][payload.Symbol] == 0{
+		delete(s.TokenBalances[tx.From], payload.Symbol)
+	}
+	s.addTokenBalance(tx.To, payload.Symbol, payload.Amount) 
+
+	return nil 
+}
+
+func (s *State) addTokenBalance(account common.Address, symbol string, amount uint){
+	if amount == 0{
+		return
+	}
+	if s.TokenBalances[account] == nil{
+		s.TokenBalances[account] = make(map[string]uint)
+	}
+	s.TokenBalances[account][symbol] += amount 
+}
+
+// AccountTokens returns the token balances of the account
+func (s *State) AccountTokens(account common.Address) map[string]uint{
+	balances := make(map[string]uint) 
+	for symbol, balance := range s.TokenBalances[account]{
+		balances[symbol] = balance 
+	}
+	return balances
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestTokenTxs(t *testing.T){
+	privKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	creator := crypto.PubkeyToAddress(privKey.PublicKey) 
+	holder := NewAccount("0x02") 
+
+	s := newTestState(map[common.Address]uint{creator: 100}) 
+
+	err = ApplyTx(signTestTx(t, NewTokenCreateTx(creator, "PTS", 2, 1000, 1), privKey), s) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	if s.Tokens["PTS"].Creator != creator || s.AccountTokens(creator)["PTS"] != 1000{
+		t.Fatalf("expected the supply owned by the creator, got %v", s.AccountTokens(creator))
+	}
+
+	tests := []struct{
+		name string 
+		tx Tx 
+		err error 
+	}{
+		{"duplicate", NewTokenCreateTx(creator, "PTS", 2, 10, 2), ErrInvalidToken}, 
+		{"bad symbol", NewTokenCreateTx(creator, "pts", 2, 10, 2), ErrInvalidToken}, 
+		{"too many decimals", NewTokenCreateTx(creator, "ABC", 19, 10, 2), ErrInvalidToken}, 
+		{"unknown token", NewTokenTransferTx(creator, holder, "XYZ", 10, 2), ErrNotFound}, 
+		{"balance", NewTokenTransferTx(creator, holder, "PTS", 1001, 2), ErrInsufficientBalance}, 
+	}
+
+	for _, test := range tests{
+		err := ValidateTx(signTestTx(t, test.tx, privKey), s) 
+		if !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err) 
+		}
+	}
+
+	err = ApplyTx(signTestTx(t, NewTokenTransferTx(creator, holder, "PTS", 400, 2), privKey), s) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	if s.AccountTokens(creator)["PTS"] != 600 || s.AccountTokens(holder)["PTS"] != 400{
+		t.Errorf("expected 600 and 400 PTS, got %v and %v", s.AccountTokens(creator), s.AccountTokens(holder))
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/elliptic" 
 	"crypto/sha256" 
 	"encoding/json" 
+	"fmt" 
+	"math/bits" 
 	"time" 
 
 	"github.com/ethereum/go-ethereum/common" 
@@ -15,6 +17,13 @@ func NewAccount(value string) common.Address{
 }
 
 
+// TxType selects the TxKind validating and applying the tx. 
+// Txs predating the types have none and are plain transfers.
+type TxType string 
+
+const TxTypeTransfer = TxType("transfer")
+const TxTypeCoinbase = TxType("coinbase")
+
 type Tx struct{
 	From common.Address `json:"from"`
 	To common.Address `json:"to"`
@@ -24,37 +33,146 @@ type Tx struct{
 	Nonce uint `json:"nonce"`
 	Data string `json:"data"`
 	Time uint64 `json:"time"`
+	MaxFee uint `json:"max_fee"`
+	MaxTip uint `json:"max_tip"`
+	Type TxType `json:"type"`
+	Payload json.RawMessage `json:"payload"`
 }
 
 type SignedTx struct{
 	Tx 
 	Sig []byte `json:"signature"`
+	// Sigs of the signers of a multisig sender, see NewMultisigTx
+	Sigs [][]byte `json:"signatures"`
 }
 
 func NewTx(from, to common.Address, gas uint, gasPrice uint, value, nonce uint, data string)Tx{
-	return Tx{from, to, gas, gasPrice, value, nonce, data, uint64(time.Now().Unix())}
+	return Tx{from, to, gas, gasPrice, value, nonce, data, uint64(time.Now().Unix()), 0, 0, TxTypeTransfer, nil}
+}
+
+// NewDynamicFeeTx creates a tx paying the block base fee, burned, plus a tip to the miner. 
+// maxFee caps the total price per gas and maxTip the tip per gas.
+func NewDynamicFeeTx(from, to common.Address, gas uint, maxFee, maxTip uint, value, nonce uint, data string) Tx{
+	return Tx{from, to, gas, 0, value, nonce, data, uint64(time.Now().Unix()), maxFee, maxTip, TxTypeTransfer, nil}
 }
 
 func NewBaseTx(from, to common.Address, value, nonce uint, data string) Tx{
 	return NewTx(from, to, TxGas, TxGasPriceDefault, value, nonce, data)
 }
 
+// NewTypedTx creates a tx of the given kind carrying its JSON encoded payload
+func NewTypedTx(from, to common.Address, txType TxType, payload interface{}, value, nonce uint) Tx{
+	tx := NewBaseTx(from, to, value, nonce, "") 
+	tx.Type = txType 
+	// Payloads are plain structs, encoding them can't fail
+	tx.Payload, _ = json.Marshal(payload) 
+
+	return tx 
+}
+
 func NewSignedTx(tx Tx, sig []byte) SignedTx{
-	return SignedTx{tx, sig} 
+	return SignedTx{tx, sig, nil} 
+}
+
+// NewCoinbaseTx creates the unsigned tx paying the block reward and fees to the miner. 
+// The block number is used as nonce so every coinbase has a distinct hash.
+func NewCoinbaseTx(miner common.Address, blockNumber uint64, blockTime uint64, value uint) SignedTx{
+	return NewSignedTx(Tx{common.Address{}, miner, 0, 0, value, uint(blockNumber), "reward", blockTime, 0, 0, TxTypeCoinbase, nil}, nil)
 }
 
 func (tx Tx) IsReward() bool{
 	return tx.Data == "reward"
 }
 
+func (tx Tx) IsCoinbase() bool{
+	if tx.Type != ""{
+		return tx.Type == TxTypeCoinbase
+	}
+	return tx.IsReward() && tx.From == common.Address{}
+}
+
+// Kind is the type of the tx, untyped txs are transfers
+func (tx Tx) Kind() TxType{
+	if tx.Type == ""{
+		return TxTypeTransfer
+	}
+	return tx.Type
+}
+
+// IsLegacy reports whether the tx predates gas and types, 
+// legacy txs keep their original encoding
+func (tx Tx) IsLegacy() bool{
+	return tx.Type == "" && tx.Gas == 0 
+}
+
+// DecodePayload decodes the payload of a typed tx into v
+func (tx Tx) DecodePayload(v interface{}) error{
+	err := json.Unmarshal(tx.Payload, v) 
+	if err != nil{
+		return fmt.Errorf("%w: %s payload. %s", ErrInvalidPayload, tx.Kind(), err.Error())
+	}
+	return nil 
+}
+
+// Cost is the most the tx can cost its sender
 func (tx Tx) Cost() uint{
+	if tx.IsDynamicFee(){
+		return tx.Value + tx.Gas*tx.MaxFee
+	}
 	return tx.Value + tx.GasCost()
 }
 
+// GasCost of a legacy priced tx, each unit of gas is paid at the gas price. See Fee for dynamic fee txs
 func (tx Tx) GasCost() uint{
+	return tx.Gas * tx.GasPrice
+}
+
+// legacyGasCost is the gas fee charged by legacy blocks, before gas was paid per unit. 
+// Replaying them must keep charging it to rebuild the same balances.
+func (tx Tx) legacyGasCost() uint{
 	return tx.Gas + tx.GasPrice
 }
 
+// costOverflows reports whether the most the tx can pay, its value and gas fee, doesn't fit in a uint
+func (tx Tx) costOverflows() bool{
+	price := tx.GasPrice 
+	if tx.IsDynamicFee(){
+		price = tx.MaxFee 
+	}
+
+	hi, fee := bits.Mul(tx.Gas, price) 
+	if hi != 0{
+		return true 
+	}
+	_, carry := bits.Add(fee, tx.Value, 0) 
+	return carry != 0 
+}
+
+func (tx Tx) IsDynamicFee() bool{
+	return tx.MaxFee > 0 
+}
+
+// Fee splits the gas fee paid at the given base fee between the burned part and the miner tip. 
+// Legacy priced txs pay their GasCost, of which the base fee is burned.
+func (tx Tx) Fee(baseFee uint) (burn uint, tip uint){
+	if !tx.IsDynamicFee(){
+		burn = tx.Gas * baseFee 
+		if burn > tx.GasCost(){
+			burn = tx.GasCost()
+		}
+		return burn, tx.GasCost() - burn 
+	}
+
+	tipPerGas := tx.MaxTip 
+	if tx.MaxFee < baseFee{
+		tipPerGas = 0 
+	} else if tipPerGas > tx.MaxFee-baseFee{
+		tipPerGas = tx.MaxFee - baseFee
+	}
+
+	return tx.Gas * baseFee, tx.Gas * tipPerGas 
+}
+
 func (tx Tx) Hash() (Hash, error){
 	txJson, err := tx.Encode() 
 	if err != nil{
@@ -70,7 +188,7 @@ func (tx Tx) Encode() ([]byte, error){
 // MarshalJson is the source of truth when it comes to 
 // encoding a transactionfor hash calculations
 func (t Tx) MarshalJSON() ([]byte, error){
-	if t.Gas == 0{
+	if t.IsLegacy(){
 		type LegacyTx struct{
 			From common.Address `json:"from"`
 			To common.Address `json:"to"`
@@ -98,6 +216,10 @@ func (t Tx) MarshalJSON() ([]byte, error){
 		Data string `json:"data"`
 		Time uint64 `json:"time"` 
 		Sig []byte `json:"signature"`
+		MaxFee uint `json:"max_fee,omitempty"`
+		MaxTip uint `json:"max_tip,omitempty"`
+		Type TxType `json:"type,omitempty"`
+		Payload json.RawMessage `json:"payload,omitempty"`
 	}
 
 	return json.Marshal(NemosTx{
@@ -109,12 +231,15 @@ func (t Tx) MarshalJSON() ([]byte, error){
 		Nonce: t.Nonce, 
 		Data: t.Data, 
 		Time: t.Time,
-
+		MaxFee: t.MaxFee, 
+		MaxTip: t.MaxTip, 
+		Type: t.Type, 
+		Payload: t.Payload, 
 	})
 }
 
 func (t SignedTx) MarshalJSON() ([]byte, error){
-	if t.Gas == 0{
+	if t.IsLegacy(){
 		type LegacyTx struct{
 			From common.Address `json:"from"`
 			To common.Address `json:"to"`
@@ -146,6 +271,11 @@ func (t SignedTx) MarshalJSON() ([]byte, error){
 		Data string `json:"data"`
 		Time uint64 `json:"time"`
 		Sig []byte `json:"signature"`
+		MaxFee uint `json:"max_fee,omitempty"`
+		MaxTip uint `json:"max_tip,omitempty"`
+		Type TxType `json:"type,omitempty"`
+		Payload json.RawMessage `json:"payload,omitempty"`
+		Sigs [][]byte `json:"signatures,omitempty"`
 	}
 
 	return json.Marshal(NemosTx{
@@ -158,6 +288,11 @@ func (t SignedTx) MarshalJSON() ([]byte, error){
 		Data: t.Data, 
 		Time: t.Time, 
 		Sig: t.Sig, 
+		MaxFee: t.MaxFee, 
+		MaxTip: t.MaxTip, 
+		Type: t.Type, 
+		Payload: t.Payload, 
+		Sigs: t.Sigs, 
 	})
 }
 
@@ -176,14 +311,22 @@ func (t SignedTx) IsAuthentic() (bool, error){
 		return false, err
 	}
 
-	recoveredPubKey, err := crypto.SigToPub(txHash[:], t.Sig) 
+	recoveredAccount, err := recoverSigner(txHash, t.Sig) 
 	if err != nil{
 		return false, err 
 	}
 
+	return recoveredAccount.Hex() == t.From.Hex(), nil 
+}
+
+func recoverSigner(txHash Hash, sig []byte) (common.Address, error){
+	recoveredPubKey, err := crypto.SigToPub(txHash[:], sig) 
+	if err != nil{
+		return common.Address{}, err 
+	}
+
 	recoveredPubKeyBytes := elliptic.Marshal(crypto.S256(), recoveredPubKey.X, recoveredPubKey.Y)
 	recoveredPubKeyHash := crypto.Keccak256(recoveredPubKeyBytes[1:])
-	recoveredAccount := common.BytesToAddress(recoveredPubKeyHash[12:])
 
-	return recoveredAccount.Hex() == t.From.Hex(), nil 
+	return common.BytesToAddress(recoveredPubKeyHash[12:]), nil 
 }
\ No newline at end of file
@@ -0,0 +1,62 @@
+package core 
+
+import (
+	"fmt" 
+)
+
+// TxKind defines how the txs of a type are validated and applied. 
+// Signature, nonce, fees and the sender ability to pay Tx.Cost are checked for every kind 
+// beforehand, and the fees are charged before Apply.
+type TxKind struct{
+	// Gas required by the tx, TxGas when nil
+	Gas func(tx Tx) uint 
+	Validate func(tx Tx, s *State) error 
+	Apply func(tx Tx, s *State) error 
+}
+
+var txKinds = make(map[TxType]TxKind)
+
+func registerTxKind(txType TxType, kind TxKind){
+	if _, exists := txKinds[txType]; exists{
+		panic(fmt.Sprintf("tx kind '%s' registered twice", txType))
+	}
+	txKinds[txType] = kind 
+}
+
+func txKindOf(tx Tx) (TxKind, error){
+	kind, ok := txKinds[tx.Kind()] 
+	if !ok{
+		return TxKind{}, fmt.Errorf("%w: '%s'", ErrUnknownTxType, tx.Kind())
+	}
+	return kind, nil 
+}
+
+// RequiredGas is the gas the tx must provide for its kind
+func (tx Tx) RequiredGas() uint{
+	kind, err := txKindOf(tx) 
+	if err != nil || kind.Gas == nil{
+		return TxGas
+	}
+	return kind.Gas(tx)
+}
+
+func init(){
+	registerTxKind(TxTypeTransfer, TxKind{
+		Apply: applyTransferTx, 
+	})
+}
+
+func applyTransferTx(tx Tx, s *State) error{
+	s.Balances[tx.From] -= tx.Value 
+	s.Balances[tx.To] += tx.Value 
+
+	return nil 
+}
+
+// validateNoValue rejects native value on kinds which don't move any
+func validateNoValue(tx Tx) error{
+	if tx.Value != 0{
+		return fmt.Errorf("%w: %s txs can't carry a value", ErrInvalidPayload, tx.Kind())
+	}
+	return nil 
+}
@@ -1 +1,76 @@
-package core
\ No newline at end of file
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestTxEncoding(t *testing.T){
+	from, to := NewAccount("0x01"), NewAccount("0x02") 
+
+	legacy := NewSignedTx(Tx{From: from, To: to, Value: 10, Nonce: 1, Time: 1}, nil) 
+	untyped := NewSignedTx(Tx{From: from, To: to, Gas: TxGas, GasPrice: 1, Value: 10, Nonce: 1, Time: 1}, nil) 
+	typed := NewSignedTx(NewTokenTransferTx(from, to, "PTS", 5, 1), nil) 
+
+	tests := []struct{
+		name string 
+		tx SignedTx 
+		kind TxType 
+		hasGas bool 
+		hasType bool 
+	}{
+		{"legacy", legacy, TxTypeTransfer, false, false}, 
+		{"untyped", untyped, TxTypeTransfer, true, false}, 
+		{"typed", typed, TxTypeTokenTransfer, true, true}, 
+	}
+
+	for _, test := range tests{
+		encoded, err := json.Marshal(test.tx) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+
+		if test.tx.Kind() != test.kind{
+			t.Errorf("%s: expected kind %s, got %s", test.name, test.kind, test.tx.Kind())
+		}
+		if strings.Contains(string(encoded), `"gas"`) != test.hasGas{
+			t.Errorf("%s: unexpected gas encoding %s", test.name, encoded)
+		}
+		if strings.Contains(string(encoded), `"type"`) != test.hasType{
+			t.Errorf("%s: unexpected type encoding %s", test.name, encoded)
+		}
+
+		decoded := SignedTx{} 
+		err = json.Unmarshal(encoded, &decoded) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+
+		hash, _ := test.tx.Hash() 
+		decodedHash, _ := decoded.Hash() 
+		if hash != decodedHash{
+			t.Errorf("%s: expected the decoded tx to keep its hash", test.name)
+		}
+	}
+}
+
+func TestUnknownTxType(t *testing.T){
+	privKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	from := crypto.PubkeyToAddress(privKey.PublicKey) 
+
+	s := newTestState(map[common.Address]uint{from: 100}) 
+
+	tx := NewTypedTx(from, from, TxType("unknown"), struct{}{}, 0, 1) 
+	err = ValidateTx(signTestTx(t, tx, privKey), s) 
+	if !errors.Is(err, ErrUnknownTxType){
+		t.Errorf("expected %s, got %v", ErrUnknownTxType, err)
+	}
+}
@@ -0,0 +1,291 @@
+package core 
+
+import (
+	"fmt" 
+	"time" 
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// validateBlock verifies the block header against the current chain tip: 
+// finality, height, parent link, consensus seal, base fee and tx root. Legacy 
+// blocks are only accepted until the first block with roots.
+func validateBlock(b Block, s *State) error{
+	nextExpectedBlockNumber := s.latestBlock.Header.Number + 1 
+
+	err := validateNotFinalized(b, s) 
+	if err != nil{
+		return err 
+	}
+
+	if s.hasGenesisBlock && b.Header.Number != nextExpectedBlockNumber{
+		return fmt.Errorf("%w: next expected block number must be '%d' not '%d'", ErrBadBlockNumber, nextExpectedBlockNumber, b.Header.Number) 
+	}
+
+	if s.hasGenesisBlock && s.latestBlock.Header.Number > 0 && b.Header.Parent != s.latestBlockHash{
+		return fmt.Errorf("%w: next block parent hash must be '%x' not '%x'", ErrBadParent, s.latestBlockHash, b.Header.Parent)
+	}
+
+	if s.hasGenesisBlock && b.Header.IsLegacy() && !s.latestBlock.Header.IsLegacy(){
+		return fmt.Errorf("%w: block '%d' has no tx root after block '%d'", ErrLegacyBlock, b.Header.Number, s.latestBlock.Header.Number)
+	}
+
+	hash, err := b.Hash()
+	if err != nil{
+		return err
+	}
+
+	err = s.engine.VerifyHeader(b.Header, hash, s.latestBlock.Header, s.Validators) 
+	if err != nil{
+		return err 
+	}
+
+	if len(s.recentBlockTimes) > 0 && b.Header.Time <= s.medianBlockTime(){
+		return fmt.Errorf("%w: block time '%d' must be after the median time of the last blocks '%d'", ErrBadTimestamp, b.Header.Time, s.medianBlockTime())
+	}
+
+	for _, tx := range b.Txs{
+		if tx.Time > b.Header.Time{
+			return fmt.Errorf("%w: tx time '%d' is after the block time '%d'", ErrBadTimestamp, tx.Time, b.Header.Time)
+		}
+	}
+
+	if !b.Header.IsLegacy(){
+		if b.Header.BaseFee != s.NextBaseFee(){
+			return fmt.Errorf("%w: block base fee must be '%d' not '%d'", ErrBadBaseFee, s.NextBaseFee(), b.Header.BaseFee)
+		}
+
+		if b.GasUsed() > BlockGasLimit{
+			return fmt.Errorf("%w: block uses %d gas, limit is %d", ErrGasLimitExceeded, b.GasUsed(), BlockGasLimit)
+		}
+
+		err := validateCoinbase(b, s) 
+		if err != nil{
+			return err 
+		}
+
+		txRoot, err := TxsRoot(b.Txs) 
+		if err != nil{
+			return err 
+		}
+		if txRoot != b.Header.TxRoot{
+			return fmt.Errorf("%w: block tx root must be '%x' not '%x'", ErrBadTxRoot, txRoot, b.Header.TxRoot)
+		}
+	}
+
+	return nil 
+}
+
+// validateCoinbase verifies the block starts with a single coinbase paying 
+// the scheduled block reward and the txs fees to the miner
+func validateCoinbase(b Block, s *State) error{
+	coinbase, ok := b.Coinbase() 
+	if !ok{
+		return fmt.Errorf("%w: block '%d' first tx must be the coinbase", ErrBadCoinbase, b.Header.Number)
+	}
+
+	for _, tx := range b.Txs[1:]{
+		if tx.IsCoinbase(){
+			return fmt.Errorf("%w: block '%d' has more than one coinbase", ErrBadCoinbase, b.Header.Number)
+		}
+	}
+
+	if coinbase.To != b.Header.Miner{
+		return fmt.Errorf("%w: coinbase must pay the miner '%s' not '%s'", ErrBadCoinbase, b.Header.Miner.String(), coinbase.To.String())
+	}
+
+	if coinbase.Nonce != uint(b.Header.Number) || coinbase.Time != b.Header.Time{
+		return fmt.Errorf("%w: coinbase nonce and time must be the block number and time", ErrBadCoinbase)
+	}
+
+	if coinbase.Gas != 0 || coinbase.GasPrice != 0 || len(coinbase.Sig) != 0{
+		return fmt.Errorf("%w: coinbase can't pay gas nor be signed", ErrBadCoinbase)
+	}
+
+	expectedValue := s.BlockRewardAt(b.Header.Number) + b.GasReward() 
+	if coinbase.Value != expectedValue{
+		return fmt.Errorf("%w: coinbase value must be '%d' not '%d'", ErrBadCoinbase, expectedValue, coinbase.Value)
+	}
+
+	return nil 
+}
+
+// validateBlockTimeDrift rejects blocks stamped too far ahead of the node clock. 
+// They may become valid later so callers can retry them.
+func validateBlockTimeDrift(b Block, maxDrift uint64) error{
+	maxTime := uint64(time.Now().Unix()) + maxDrift 
+	if b.Header.Time > maxTime{
+		return fmt.Errorf("%w: block time '%d' is more than %ds ahead", ErrBlockFromFuture, b.Header.Time, maxDrift)
+	}
+	return nil 
+}
+
+// validateValidators verifies the header announces the validator set when the block txs changed it
+func validateValidators(b Block, before []common.Address, s *State) error{
+	var expected []common.Address 
+	if !sameAccounts(before, s.Validators){
+		expected = s.Validators 
+	}
+
+	if !sameAccounts(expected, b.Header.Validators){
+		return fmt.Errorf("%w: block '%d' must announce the validators %v not %v", ErrBadValidators, b.Header.Number, expected, b.Header.Validators)
+	}
+	return nil 
+}
+
+// validateStateRoot verifies the state resulting from the block matches its header
+func validateStateRoot(b Block, s *State) error{
+	if b.Header.IsLegacy(){
+		return nil 
+	}
+
+	stateRoot, err := s.StateRoot() 
+	if err != nil{
+		return err 
+	}
+	if stateRoot != b.Header.StateRoot{
+		return fmt.Errorf("%w: block state root must be '%x' not '%x'", ErrBadStateRoot, stateRoot, b.Header.StateRoot)
+	}
+
+	return nil 
+}
+
+// ValidateHeaderChain verifies the headers extend the parent header (or start the chain 
+// when hasParent is false) and are sealed by the validators in charge of each of them. 
+// Legacy headers can't be hashed on their own so only their announced hash is checked, 
+// callers must verify it against the block body.
+func ValidateHeaderChain(headers []BlockHeaderFS, parent BlockHeaderFS, hasParent bool, engine Engine, validators []common.Address) error{
+	parentHash, parentNumber := parent.Key, parent.Value.Number 
+
+	for _, h := range headers{
+		expectedNumber := uint64(0) 
+		if hasParent{
+			expectedNumber = parentNumber + 1
+		}
+
+		if h.Value.Number != expectedNumber{
+			return fmt.Errorf("%w: next expected header number must be '%d' not '%d'", ErrBadBlockNumber, expectedNumber, h.Value.Number)
+		}
+
+		if hasParent && h.Value.Parent != parentHash{
+			return fmt.Errorf("%w: header '%d' parent hash must be '%x' not '%x'", ErrBadParent, h.Value.Number, parentHash, h.Value.Parent)
+		}
+
+		if hasParent && h.Value.IsLegacy() && !parent.Value.IsLegacy(){
+			return fmt.Errorf("%w: header '%d' has no tx root after header '%d'", ErrLegacyBlock, h.Value.Number, parentNumber)
+		}
+
+		if !h.Value.IsLegacy(){
+			hash, err := h.Value.Hash() 
+			if err != nil{
+				return err 
+			}
+			if hash != h.Key{
+				return fmt.Errorf("%w: header '%d' announced '%x' but hashes to '%x'", ErrBadBlockHash, h.Value.Number, h.Key, hash) 
+			}
+		}
+
+		err := engine.VerifyHeader(h.Value, h.Key, parent.Value, validators) 
+		if err != nil{
+			return err 
+		}
+
+		// The validators announced by a header seal the headers after it
+		validators = ValidatorsAfter(validators, []BlockHeaderFS{h}) 
+
+		parent = h 
+		parentHash = h.Key 
+		parentNumber = h.Value.Number 
+		hasParent = true 
+	}
+
+	return nil 
+}
+
+// ValidateTx verifies the tx can be included in the next block
+func ValidateTx(tx SignedTx, s *State) error{
+	return validateTx(tx, s, s.NextBaseFee(), false)
+}
+
+// validateTx verifies the tx at the given base fee. The txs of legacy blocks 
+// are checked against the legacy gas cost they were charged.
+func validateTx(tx SignedTx, s *State, baseFee uint, legacy bool) error{
+	if tx.IsCoinbase(){
+		return fmt.Errorf("%w: coinbase txs are only valid as the first tx of a block", ErrBadCoinbase)
+	}
+
+	kind, err := txKindOf(tx.Tx) 
+	if err != nil{
+		return err 
+	}
+
+	err = validateTxSignature(tx, s) 
+	if err != nil{
+		return err 
+	}
+
+	expectedNonce := s.GetNextAccountNonce(tx.From) 
+	if tx.Nonce != expectedNonce{
+		return fmt.Errorf("%w: wrong Tx. Sender '%s' next nonce must be '%d', not '%d'", ErrInvalidNonce, tx.From.String(), expectedNonce, tx.Nonce)
+	}
+
+	if tx.Gas != tx.RequiredGas(){
+		return fmt.Errorf("%w: insufficient Tx Gas %v. required: %v", ErrInsufficientGas, tx.Gas, tx.RequiredGas()) 
+	}
+
+	if tx.IsDynamicFee(){
+		if tx.MaxTip > tx.MaxFee{
+			return fmt.Errorf("%w: Tx max tip %v is above its max fee %v", ErrFeeTooLow, tx.MaxTip, tx.MaxFee)
+		}
+		if tx.MaxFee < baseFee{
+			return fmt.Errorf("%w: Tx max fee %v is below the base fee %v", ErrFeeTooLow, tx.MaxFee, baseFee)
+		}
+	} else{
+		if tx.GasPrice < TxGasPriceDefault{
+			return fmt.Errorf("%w: insufficient Tx gasPrice %v. required at least: %v", ErrInsufficientGas, tx.GasPrice, TxGasPriceDefault)
+		}
+		if tx.GasPrice < baseFee{
+			return fmt.Errorf("%w: Tx gasPrice %v is below the base fee %v", ErrFeeTooLow, tx.GasPrice, baseFee)
+		}
+	}
+
+	cost := tx.Cost() 
+	if legacy{
+		cost = tx.Value + tx.legacyGasCost() 
+	} else if tx.costOverflows(){
+		return fmt.Errorf("%w: Tx value and gas fee overflow", ErrInvalidPayload)
+	}
+
+	if cost > s.Balances[tx.From]{
+		return fmt.Errorf("%w: wrong TX. Sender '%s' balance is %d NEM. Tx cost is %d NEM", ErrInsufficientBalance, tx.From.String(), s.Balances[tx.From], cost)
+	}
+
+	if kind.Validate != nil{
+		return kind.Validate(tx.Tx, s)
+	}
+
+	return nil 
+}
+
+// validateTxSignature verifies the tx is signed by its sender, 
+// or by enough of its signers for a multisig sender
+func validateTxSignature(tx SignedTx, s *State) error{
+	if account, isMultisig := s.Multisigs[tx.From]; isMultisig{
+		return validateMultisigSigs(tx, account)
+	}
+
+	if len(tx.Sigs) != 0{
+		return fmt.Errorf("%w: '%s' isn't a multisig account", ErrInvalidSignature, tx.From.String())
+	}
+
+	ok, err := tx.IsAuthentic() 
+	if err != nil{
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error()) 
+	}
+
+	if !ok{
+		return fmt.Errorf("%w: wrong TX. Sender is '%s' is forged", ErrInvalidSignature, tx.From.String())
+	}
+
+	return nil 
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func signTestTx(t *testing.T, tx Tx, privKey *ecdsa.PrivateKey) SignedTx{
+	rawTx, err := tx.Encode() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	txHash := sha256.Sum256(rawTx) 
+	sig, err := crypto.Sign(txHash[:], privKey) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	return NewSignedTx(tx, sig)
+}
+
+func newTestState(balances map[common.Address]uint) *State{
+	return &State{
+		Balances: balances, 
+		AccountToNonce: make(map[common.Address]uint), 
+		HashCache: make(map[string]int64), 
+		HeightCache: make(map[uint64]int64), 
+		emission: DefaultEmissionSchedule, 
+		Tokens: make(map[string]Token), 
+		TokenBalances: make(map[common.Address]map[string]uint), 
+		Multisigs: make(map[common.Address]MultisigAccount), 
+		Locks: make(map[Hash]Lock), 
+		Contracts: make(map[common.Address]Contract), 
+		ContractStorage: make(map[common.Address]map[Hash]Hash), 
+		ValidatorVotes: make(map[ValidatorProposal]map[common.Address]bool), 
+		engine: NewPoWEngine(0), 
+		finality: DefaultFinalityConfig, 
+		checkpoints: make(map[uint64]Hash), 
+		CheckpointVotes: make(map[Checkpoint]map[common.Address]bool), 
+		anchors: make(map[Hash]Anchor), 
+	}
+}
+
+func TestValidateTx(t *testing.T){
+	privKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	from := crypto.PubkeyToAddress(privKey.PublicKey) 
+	to := NewAccount("0x02") 
+
+	s := newTestState(map[common.Address]uint{from: 100}) 
+
+	tests := []struct{
+		name string 
+		tx SignedTx 
+		err error 
+	}{
+		{"valid", signTestTx(t, NewBaseTx(from, to, 10, 1, ""), privKey), nil}, 
+		{"bad nonce", signTestTx(t, NewBaseTx(from, to, 10, 2, ""), privKey), ErrInvalidNonce}, 
+		{"no gas", signTestTx(t, NewTx(from, to, 0, 1, 10, 1, ""), privKey), ErrInsufficientGas}, 
+		{"balance", signTestTx(t, NewBaseTx(from, to, 1000, 1, ""), privKey), ErrInsufficientBalance}, 
+		{"forged", signTestTx(t, NewBaseTx(to, from, 10, 1, ""), privKey), ErrInvalidSignature}, 
+	}
+
+	for _, test := range tests{
+		err := ValidateTx(test.tx, s) 
+		if test.err == nil && err != nil{
+			t.Errorf("%s: expected no error, got %s", test.name, err) 
+		}
+		if test.err != nil && !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err) 
+		}
+	}
+}
+
+func mineTestBlock(b Block) Block{
+	for nonce := uint32(0); ; nonce++{
+		b.Header.Nonce = nonce 
+		hash, _ := b.Hash() 
+		if IsBlockHashValid(hash, 0){
+			return b
+		}
+	}
+}
+
+func TestValidateBlockTime(t *testing.T){
+	s := newTestState(map[common.Address]uint{}) 
+	s.recentBlockTimes = []uint64{10, 30, 20} 
+
+	early := mineTestBlock(NewBlock(Hash{}, 0, 0, 20, NewAccount("0x01"), nil)) 
+	err := validateBlock(early, s) 
+	if !errors.Is(err, ErrBadTimestamp){
+		t.Errorf("expected %s for a block at the median time, got %v", ErrBadTimestamp, err) 
+	}
+
+	coinbase := NewCoinbaseTx(NewAccount("0x01"), 0, 21, BlockReward) 
+	valid := NewBlock(Hash{}, 0, 0, 21, NewAccount("0x01"), []SignedTx{coinbase}) 
+	valid.Header.BaseFee = InitialBaseFee 
+	valid = mineTestBlock(valid) 
+	err = validateBlock(valid, s) 
+	if err != nil{
+		t.Errorf("expected a valid block time, got %s", err) 
+	}
+
+	tx := NewSignedTx(NewBaseTx(NewAccount("0x01"), NewAccount("0x02"), 1, 1, ""), nil) 
+	tx.Time = 22 
+	txAfterBlock := mineTestBlock(NewBlock(Hash{}, 0, 0, 21, NewAccount("0x01"), []SignedTx{coinbase, tx})) 
+	err = validateBlock(txAfterBlock, s) 
+	if !errors.Is(err, ErrBadTimestamp){
+		t.Errorf("expected %s for a tx after its block, got %v", ErrBadTimestamp, err) 
+	}
+
+	future := NewBlock(Hash{}, 0, 0, uint64(time.Now().Unix())+DefaultMaxBlockTimeDrift+60, NewAccount("0x01"), nil) 
+	err = validateBlockTimeDrift(future, DefaultMaxBlockTimeDrift) 
+	if !errors.Is(err, ErrBlockFromFuture){
+		t.Errorf("expected %s, got %v", ErrBlockFromFuture, err) 
+	}
+}
+
+func TestValidateLegacyBlock(t *testing.T){
+	miner := NewAccount("0x01") 
+	s := newTestState(map[common.Address]uint{}) 
+
+	legacyParent := NewBlock(Hash{}, 0, 0, 1, miner, nil) 
+	legacyParent.Header.TxRoot = Hash{} 
+	legacyParent = mineTestBlock(legacyParent) 
+	legacyParentHash, _ := legacyParent.Hash() 
+
+	s.latestBlock, s.latestBlockHash, s.hasGenesisBlock = legacyParent, legacyParentHash, true 
+
+	legacy := NewBlock(legacyParentHash, 1, 0, 2, miner, nil) 
+	legacy.Header.TxRoot = Hash{} 
+	err := validateBlock(mineTestBlock(legacy), s) 
+	if err != nil{
+		t.Errorf("expected a legacy block on a legacy chain to be valid, got %s", err) 
+	}
+
+	rootedParent := mineTestBlock(NewBlock(Hash{}, 0, 0, 1, miner, nil)) 
+	rootedParentHash, _ := rootedParent.Hash() 
+
+	s.latestBlock, s.latestBlockHash = rootedParent, rootedParentHash 
+
+	// Skipping the tx root would skip the coinbase, base fee, gas limit and state root checks
+	legacy = NewBlock(rootedParentHash, 1, 0, 2, miner, nil) 
+	legacy.Header.TxRoot = Hash{} 
+	err = validateBlock(mineTestBlock(legacy), s) 
+	if !errors.Is(err, ErrLegacyBlock){
+		t.Errorf("expected %s for a legacy block after a rooted one, got %v", ErrLegacyBlock, err) 
+	}
+}
+
+func TestValidateCoinbase(t *testing.T){
+	miner := NewAccount("0x01") 
+	tx := NewSignedTx(NewBaseTx(NewAccount("0x02"), NewAccount("0x03"), 1, 1, ""), nil) 
+
+	tests := []struct{
+		name string 
+		txs []SignedTx 
+		err error 
+	}{
+		{"valid", []SignedTx{NewCoinbaseTx(miner, 1, 5, BlockReward+tx.GasCost()), tx}, nil}, 
+		{"missing", []SignedTx{tx}, ErrBadCoinbase}, 
+		{"not first", []SignedTx{tx, NewCoinbaseTx(miner, 1, 5, BlockReward+tx.GasCost())}, ErrBadCoinbase}, 
+		{"wrong value", []SignedTx{NewCoinbaseTx(miner, 1, 5, BlockReward+tx.GasCost()+1), tx}, ErrBadCoinbase}, 
+		{"wrong miner", []SignedTx{NewCoinbaseTx(NewAccount("0x02"), 1, 5, BlockReward+tx.GasCost()), tx}, ErrBadCoinbase}, 
+	}
+
+	for _, test := range tests{
+		err := validateCoinbase(NewBlock(Hash{}, 1, 0, 5, miner, test.txs), newTestState(map[common.Address]uint{})) 
+		if test.err == nil && err != nil{
+			t.Errorf("%s: expected no error, got %s", test.name, err) 
+		}
+		if test.err != nil && !errors.Is(err, test.err){
+			t.Errorf("%s: expected error %s, got %v", test.name, test.err, err) 
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package core 
+
+import (
+	"fmt" 
+	"math/big" 
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The VM runs contract bytecode on a stack of 256 bits words. 
+// Arithmetic wraps around 2^256, divisions by zero give zero.
+type OpCode byte 
+
+const (
+	OpStop OpCode = 0x00 
+	// OpPush is followed by the count of bytes to push (1 to 32) and the bytes
+	OpPush OpCode = 0x01 
+	OpPop OpCode = 0x02 
+	OpDup OpCode = 0x03 
+	OpSwap OpCode = 0x04 
+
+	OpAdd OpCode = 0x10 
+	OpSub OpCode = 0x11 
+	OpMul OpCode = 0x12 
+	OpDiv OpCode = 0x13 
+	OpMod OpCode = 0x14 
+	OpLt OpCode = 0x15 
+	OpGt OpCode = 0x16 
+	OpEq OpCode = 0x17 
+	OpIsZero OpCode = 0x18 
+
+	// OpJump pops the destination, OpJumpI the destination then the condition
+	OpJump OpCode = 0x20 
+	OpJumpI OpCode = 0x21 
+
+	// OpSLoad pops a key, OpSStore pops a key then the value to store
+	OpSLoad OpCode = 0x30 
+	OpSStore OpCode = 0x31 
+
+	OpCaller OpCode = 0x40 
+	OpCallValue OpCode = 0x41 
+	// OpArg pops the index of the call argument to push
+	OpArg OpCode = 0x42 
+	OpNumber OpCode = 0x43 
+	OpTime OpCode = 0x44 
+	OpSelf OpCode = 0x45 
+	OpBalance OpCode = 0x46 
+
+	OpReturn OpCode = 0x50 
+	OpRevert OpCode = 0x51 
+	// OpTransfer pops the recipient then the value paid from the contract balance
+	OpTransfer OpCode = 0x52 
+)
+
+const vmMaxStackSize = 256
+
+const (
+	vmGasDefault = uint(1) 
+	vmGasSLoad = uint(5) 
+	vmGasSStore = uint(20) 
+	vmGasTransfer = uint(10) 
+)
+
+var wordModulus = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// WordFromUint encodes a number as a VM word, e.g. a call argument
+func WordFromUint(value uint64) Hash{
+	return wordFromBig(new(big.Int).SetUint64(value))
+}
+
+func WordFromAddress(account common.Address) Hash{
+	return Hash(common.BytesToHash(account[:]))
+}
+
+func wordFromBig(value *big.Int) Hash{
+	value = new(big.Int).Mod(value, wordModulus) 
+
+	word := Hash{} 
+	value.FillBytes(word[:]) 
+	return word 
+}
+
+func wordToBig(word Hash) *big.Int{
+	return new(big.Int).SetBytes(word[:])
+}
+
+func wordFromBool(b bool) Hash{
+	if b{
+		return WordFromUint(1)
+	}
+	return Hash{}
+}
+
+// vmCall is the environment of a contract execution. Storage writes and 
+// transfers are buffered and only committed once the execution succeeds.
+type vmCall struct{
+	s *State 
+	contract common.Address 
+	caller common.Address 
+	value uint 
+	args []Hash 
+	gasLimit uint 
+
+	writes map[Hash]Hash 
+	transfers []TxOutput 
+	transferred uint 
+}
+
+func newVMCall(s *State, contract, caller common.Address, value uint, args []Hash, gasLimit uint) *vmCall{
+	return &vmCall{
+		s: s, 
+		contract: contract, 
+		caller: caller, 
+		value: value, 
+		args: args, 
+		gasLimit: gasLimit, 
+		writes: make(map[Hash]Hash), 
+	}
+}
+
+func (c *vmCall) load(key Hash) Hash{
+	if value, ok := c.writes[key]; ok{
+		return value 
+	}
+	return c.s.ContractStorage[c.contract][key]
+}
+
+// balance available to the contract, including the call value
+func (c *vmCall) balance() uint{
+	return c.s.Balances[c.contract] + c.value - c.transferred
+}
+
+// commit applies the storage writes and transfers of a successful execution
+func (c *vmCall) commit(){
+	if len(c.writes) > 0 && c.s.ContractStorage[c.contract] == nil{
+		c.s.ContractStorage[c.contract] = make(map[Hash]Hash)
+	}
+
+	for key, value := range c.writes{
+		if value.IsEmpty(){
+			delete(c.s.ContractStorage[c.contract], key)
+			continue
+		}
+		c.s.ContractStorage[c.contract][key] = value 
+	}
+
+	for _, out := range c.transfers{
+		c.s.Balances[c.contract] -= out.Value 
+		c.s.Balances[out.To] += out.Value 
+	}
+}
+
+// run executes the code and returns the word it returned and the gas it used
+func (c *vmCall) run(code []byte) (Hash, uint, error){
+	stack := make([]Hash, 0, 16) 
+	gasUsed := uint(0) 
+
+	pop := func() (Hash, error){
+		if len(stack) == 0{
+			return Hash{}, fmt.Errorf("%w: stack underflow", ErrContractFailed)
+		}
+		top := stack[len(stack)-1] 
+		stack = stack[:len(stack)-1] 
+		return top, nil 
+	}
+	push := func(word Hash) error{
+		if len(stack) >= vmMaxStackSize{
+			return fmt.Errorf("%w: stack overflow", ErrContractFailed)
+		}
+		stack = append(stack, word) 
+		return nil 
+	}
+	binaryOp := func(op func(a, b *big.Int) *big.Int) error{
+		a, err := pop() 
+		if err != nil{
+			return err 
+		}
+		b, err := pop() 
+		if err != nil{
+			return err 
+		}
+		return push(wordFromBig(op(wordToBig(a), wordToBig(b))))
+	}
+
+	for pc := 0; pc < len(code); pc++{
+		op := OpCode(code[pc]) 
+
+		cost := vmGasDefault 
+		switch op{
+		case OpSLoad: 
+			cost = vmGasSLoad 
+		case OpSStore: 
+			cost = vmGasSStore 
+		case OpTransfer: 
+			cost = vmGasTransfer 
+		}
+		gasUsed += cost 
+		if gasUsed > c.gasLimit{
+			return Hash{}, gasUsed, fmt.Errorf("%w: execution needs more than %d gas", ErrOutOfGas, c.gasLimit)
+		}
+
+		var err error 
+
+		switch op{
+		case OpStop: 
+			return Hash{}, gasUsed, nil 
+
+		case OpPush: 
+			if pc+1 >= len(code){
+				return Hash{}, gasUsed, fmt.Errorf("%w: push at %d has no size", ErrContractFailed, pc)
+			}
+			size := int(code[pc+1]) 
+			if size == 0 || size > len(Hash{}) || pc+1+size >= len(code){
+				return Hash{}, gasUsed, fmt.Errorf("%w: invalid push of %d bytes at %d", ErrContractFailed, size, pc)
+			}
+			err = push(Hash(common.BytesToHash(code[pc+2 : pc+2+size]))) 
+			pc += 1 + size 
+
+		case OpPop: 
+			_, err = pop() 
+
+		case OpDup: 
+			var top Hash 
+			top, err = pop() 
+			if err == nil{
+				push(top) 
+				err = push(top) 
+			}
+
+		case OpSwap: 
+			var a, b Hash 
+			a, err = pop() 
+			if err == nil{
+				b, err = pop() 
+			}
+			if err == nil{
+				push(a) 
+				err = push(b) 
+			}
+
+		case OpAdd: 
+			err = binaryOp(func(a, b *big.Int) *big.Int{ return a.Add(a, b) }) 
+		case OpSub: 
+			err = binaryOp(func(a, b *big.Int) *big.Int{ return a.Sub(a, b) }) 
+		case OpMul: 
+			err = binaryOp(func(a, b *big.Int) *big.Int{ return a.Mul(a, b) }) 
+		case OpDiv: 
+			err = binaryOp(func(a, b *big.Int) *big.Int{
+				if b.Sign() == 0{
+					return b
+				}
+				return a.Div(a, b)
+			}) 
+		case OpMod: 
+			err = binaryOp(func(a, b *big.Int) *big.Int{
+				if b.Sign() == 0{
+					return b
+				}
+				return a.Mod(a, b)
+			}) 
+		case OpLt: 
+			err = binaryOp(func(a, b *big.Int) *big.Int{ return wordToBig(wordFromBool(a.Cmp(b) < 0)) }) 
+		case OpGt: 
+			err = binaryOp(func(a, b *big.Int) *big.Int{ return wordToBig(wordFromBool(a.Cmp(b) > 0)) }) 
+		case OpEq: 
+			err = binaryOp(func(a, b *big.Int) *big.Int{ return wordToBig(wordFromBool(a.Cmp(b) == 0)) }) 
+		case OpIsZero: 
+			var a Hash 
+			a, err = pop() 
+			if err == nil{
+				err = push(wordFromBool(a.IsEmpty()))
+			}
+
+		case OpJump, OpJumpI: 
+			var dest, cond Hash 
+			dest, err = pop() 
+			if err == nil && op == OpJumpI{
+				cond, err = pop() 
+			}
+			if err != nil{
+				break
+			}
+			if op == OpJumpI && cond.IsEmpty(){
+				break
+			}
+			target := wordToBig(dest) 
+			if !target.IsUint64() || target.Uint64() >= uint64(len(code)){
+				return Hash{}, gasUsed, fmt.Errorf("%w: invalid jump destination at %d", ErrContractFailed, pc)
+			}
+			// The loop increments pc
+			pc = int(target.Uint64()) - 1 
+
+		case OpSLoad: 
+			var key Hash 
+			key, err = pop() 
+			if err == nil{
+				err = push(c.load(key))
+			}
+
+		case OpSStore: 
+			var key, value Hash 
+			key, err = pop() 
+			if err == nil{
+				value, err = pop() 
+			}
+			if err == nil{
+				c.writes[key] = value 
+			}
+
+		case OpCaller: 
+			err = push(WordFromAddress(c.caller)) 
+		case OpCallValue: 
+			err = push(WordFromUint(uint64(c.value))) 
+		case OpArg: 
+			var index Hash 
+			index, err = pop() 
+			if err == nil{
+				arg := Hash{} 
+				i := wordToBig(index) 
+				if i.IsUint64() && i.Uint64() < uint64(len(c.args)){
+					arg = c.args[i.Uint64()]
+				}
+				err = push(arg)
+			}
+		case OpNumber: 
+			err = push(WordFromUint(c.s.NextBlockNumber())) 
+		case OpTime: 
+			err = push(WordFromUint(c.s.latestBlock.Header.Time)) 
+		case OpSelf: 
+			err = push(WordFromAddress(c.contract)) 
+		case OpBalance: 
+			err = push(WordFromUint(uint64(c.balance()))) 
+
+		case OpReturn: 
+			var result Hash 
+			result, err = pop() 
+			if err == nil{
+				return result, gasUsed, nil 
+			}
+
+		case OpRevert: 
+			return Hash{}, gasUsed, fmt.Errorf("%w: reverted at %d", ErrContractFailed, pc)
+
+		case OpTransfer: 
+			var to, amount Hash 
+			to, err = pop() 
+			if err == nil{
+				amount, err = pop() 
+			}
+			if err != nil{
+				break
+			}
+			value := wordToBig(amount) 
+			if !value.IsUint64() || value.Uint64() > uint64(c.balance()){
+				return Hash{}, gasUsed, fmt.Errorf("%w: transfer of %s exceeds the contract balance %d", ErrContractFailed, value.String(), c.balance())
+			}
+			c.transfers = append(c.transfers, TxOutput{common.BytesToAddress(to[12:]), uint(value.Uint64())})
+			c.transferred += uint(value.Uint64()) 
+
+		default: 
+			return Hash{}, gasUsed, fmt.Errorf("%w: invalid opcode 0x%02x at %d", ErrContractFailed, byte(op), pc)
+		}
+
+		if err != nil{
+			return Hash{}, gasUsed, err 
+		}
+	}
+
+	return Hash{}, gasUsed, nil 
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func push(value byte) []byte{
+	return []byte{byte(OpPush), 1, value}
+}
+
+func program(parts ...[]byte) []byte{
+	code := make([]byte, 0) 
+	for _, part := range parts{
+		code = append(code, part...)
+	}
+	return code 
+}
+
+func TestVM(t *testing.T){
+	s := newTestState(map[common.Address]uint{}) 
+	contract := NewAccount("0xc0") 
+
+	tests := []struct{
+		name string 
+		code []byte 
+		args []Hash 
+		result uint64 
+		err error 
+	}{
+		{"arithmetic", program(push(2), push(3), []byte{byte(OpMul)}, push(1), []byte{byte(OpSwap), byte(OpSub), byte(OpReturn)}), nil, 5, nil}, 
+		{"args", program(push(1), []byte{byte(OpArg), byte(OpReturn)}), []Hash{WordFromUint(1), WordFromUint(7)}, 7, nil}, 
+		{"jump", program(push(1), push(11), []byte{byte(OpJumpI)}, push(1), []byte{byte(OpReturn)}, push(2), []byte{byte(OpReturn)}), nil, 2, nil}, 
+		{"loop", program(push(0), []byte{byte(OpJump)}), nil, 0, ErrOutOfGas}, 
+		{"revert", []byte{byte(OpRevert)}, nil, 0, ErrContractFailed}, 
+		{"underflow", []byte{byte(OpAdd)}, nil, 0, ErrContractFailed}, 
+		{"invalid opcode", []byte{0xff}, nil, 0, ErrContractFailed}, 
+	}
+
+	for _, test := range tests{
+		result, _, err := newVMCall(s, contract, NewAccount("0x01"), 0, test.args, 100).run(test.code) 
+		if test.err != nil{
+			if !errors.Is(err, test.err){
+				t.Errorf("%s: expected error %s, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil{
+			t.Errorf("%s: expected no error, got %s", test.name, err)
+			continue
+		}
+		if result != WordFromUint(test.result){
+			t.Errorf("%s: expected %d, got %s", test.name, test.result, result.Hex())
+		}
+	}
+}
+
+func TestContractTxs(t *testing.T){
+	privKey, err := crypto.GenerateKey() 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	from := crypto.PubkeyToAddress(privKey.PublicKey) 
+
+	s := newTestState(map[common.Address]uint{from: 1000}) 
+
+	// counter: storage[0] += 1, reverts once it reaches the first arg
+	counter := program(
+		push(0), []byte{byte(OpSLoad)}, push(1), []byte{byte(OpAdd), byte(OpDup)}, 
+		push(0), []byte{byte(OpArg), byte(OpEq)}, push(23), []byte{byte(OpJumpI)}, 
+		push(0), []byte{byte(OpSStore), byte(OpStop), byte(OpRevert)}, 
+	) 
+
+	deploy := NewContractDeployTx(from, counter, 0, 1) 
+	err = ApplyTx(signTestTx(t, deploy, privKey), s) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	call := func(nonce uint) error{
+		return ApplyTx(signTestTx(t, NewContractCallTx(from, deploy.To, []Hash{WordFromUint(3)}, 50, 0, nonce), privKey), s)
+	}
+
+	for nonce := uint(2); nonce <= 3; nonce++{
+		err = call(nonce) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+	}
+
+	value, _ := s.ContractStorageAt(deploy.To, Hash{}) 
+	if value != WordFromUint(2){
+		t.Fatalf("expected the counter at 2, got %s", value.Hex())
+	}
+
+	// The third increment reverts, it is still mined and pays its whole gas limit
+	reverted := signTestTx(t, NewContractCallTx(from, deploy.To, []Hash{WordFromUint(3)}, 50, 7, 4), privKey) 
+	if packed := s.PackTxs([]SignedTx{reverted}); len(packed) != 1{
+		t.Fatalf("expected the reverted call to be packed in the next block")
+	}
+
+	balance := s.Balances[from] 
+	burn, tip := reverted.Fee(s.NextBaseFee()) 
+	err = ApplyTx(reverted, s) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	if value, _ := s.ContractStorageAt(deploy.To, Hash{}); value != WordFromUint(2) || s.Balances[deploy.To] != 0{
+		t.Errorf("expected a reverted call to leave the contract untouched")
+	}
+	if burn+tip != 50 || s.Balances[from] != balance-burn-tip || s.GetNextAccountNonce(from) != 5{
+		t.Errorf("expected a reverted call to charge its gas limit and bump the nonce, got balance %d", s.Balances[from])
+	}
+
+	result, err := s.CallContract(deploy.To, from, []Hash{WordFromUint(5)}) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+	if result.GasUsed == 0{
+		t.Errorf("expected the read only call to report its gas")
+	}
+	if value, _ := s.ContractStorageAt(deploy.To, Hash{}); value != WordFromUint(2){
+		t.Errorf("expected a read only call to leave the storage untouched")
+	}
+}
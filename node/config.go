@@ -0,0 +1,319 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/irononet/nemos/core"
+	"github.com/irononet/nemos/fs"
+)
+
+// ConfigEnvPrefix of the env vars overriding the config file, named
+// NEMOS_<SECTION>_<KEY> such as NEMOS_NETWORK_PORT
+const ConfigEnvPrefix = "NEMOS"
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+var logFormats = []string{"text", "json"}
+
+// Config of `nemos run`. The defaults are overridden by the config file, then by the env vars
+// and finally by the command line flags.
+type Config struct {
+	Network NetworkConfig `json:"network" yaml:"network" toml:"network"`
+	Mining  MiningConfig  `json:"mining" yaml:"mining" toml:"mining"`
+	Storage StorageConfig `json:"storage" yaml:"storage" toml:"storage"`
+	API     APIConfig     `json:"api" yaml:"api" toml:"api"`
+	Logging LoggingConfig `json:"logging" yaml:"logging" toml:"logging"`
+	Peers   PeersConfig   `json:"peers" yaml:"peers" toml:"peers"`
+}
+
+type NetworkConfig struct {
+	IP             string `json:"ip" yaml:"ip" toml:"ip"`
+	Port           uint64 `json:"port" yaml:"port" toml:"port"`
+	Light          bool   `json:"light" yaml:"light" toml:"light"`
+	BlockTimeDrift uint64 `json:"block_time_drift" yaml:"block_time_drift" toml:"block_time_drift"`
+}
+
+type MiningConfig struct {
+	Miner            string   `json:"miner" yaml:"miner" toml:"miner"`
+	Enabled          bool     `json:"enabled" yaml:"enabled" toml:"enabled"`
+	AllowEmptyBlocks bool     `json:"allow_empty_blocks" yaml:"allow_empty_blocks" toml:"allow_empty_blocks"`
+	MinTxs           int      `json:"min_txs" yaml:"min_txs" toml:"min_txs"`
+	MaxBlockSize     int      `json:"max_block_size" yaml:"max_block_size" toml:"max_block_size"`
+	Interval         Duration `json:"interval" yaml:"interval" toml:"interval"`
+	Difficulty       uint     `json:"difficulty" yaml:"difficulty" toml:"difficulty"`
+	Signer           bool     `json:"signer" yaml:"signer" toml:"signer"`
+}
+
+type StorageConfig struct {
+	DataDir string `json:"data_dir" yaml:"data_dir" toml:"data_dir"`
+}
+
+type APIConfig struct {
+	DisableSSL bool   `json:"disable_ssl" yaml:"disable_ssl" toml:"disable_ssl"`
+	SSLEmail   string `json:"ssl_email" yaml:"ssl_email" toml:"ssl_email"`
+}
+
+type LoggingConfig struct {
+	Level  string `json:"level" yaml:"level" toml:"level"`
+	Format string `json:"format" yaml:"format" toml:"format"`
+}
+
+type PeersConfig struct {
+	BootstrapIP      string   `json:"bootstrap_ip" yaml:"bootstrap_ip" toml:"bootstrap_ip"`
+	BootstrapPort    uint64   `json:"bootstrap_port" yaml:"bootstrap_port" toml:"bootstrap_port"`
+	BootstrapAccount string   `json:"bootstrap_account" yaml:"bootstrap_account" toml:"bootstrap_account"`
+	Bootstraps       []string `json:"bootstraps" yaml:"bootstraps" toml:"bootstraps"`
+	PeersFile        string   `json:"peers_file" yaml:"peers_file" toml:"peers_file"`
+}
+
+// Duration is encoded as a string such as "10s"
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Network: NetworkConfig{
+			IP:             DefaultIP,
+			Port:           HttpSSLPort,
+			BlockTimeDrift: core.DefaultMaxBlockTimeDrift,
+		},
+		Mining: MiningConfig{
+			Miner:            DefaultMiner,
+			Enabled:          DefaultMiningPolicy.Enabled,
+			AllowEmptyBlocks: DefaultMiningPolicy.AllowEmptyBlocks,
+			MinTxs:           DefaultMiningPolicy.MinTxs,
+			MaxBlockSize:     DefaultMiningPolicy.MaxBlockSize,
+			Interval:         Duration(DefaultMiningPolicy.Interval),
+			Difficulty:       DefaultMiningDifficulty,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Peers: PeersConfig{
+			BootstrapIP:      DefaultBootstrapIp,
+			BootstrapPort:    HttpSSLPort,
+			BootstrapAccount: DefaultBootstrapAcc,
+		},
+	}
+}
+
+// LoadConfig reads the config file, JSON, YAML or TOML by extension, over the defaults and
+// applies the env var overrides. Without a path only the env vars are applied. The data dir
+// is expanded like the --dataDir flag.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+
+		values, err := decodeConfigFile(filepath.Ext(path), content)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid config file '%s'. %s", path, err.Error())
+		}
+
+		// Decoding the file values as JSON keeps the defaults of the missing keys
+		valuesJson, err := json.Marshal(values)
+		if err != nil {
+			return Config{}, err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(valuesJson))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&cfg)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid config file '%s'. %s", path, err.Error())
+		}
+	}
+
+	err := cfg.applyEnv(os.LookupEnv)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if cfg.Storage.DataDir != "" {
+		cfg.Storage.DataDir = fs.ExpandPath(cfg.Storage.DataDir)
+	}
+
+	return cfg, nil
+}
+
+func decodeConfigFile(ext string, content []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+
+	switch strings.ToLower(ext) {
+	case ".json":
+		return values, json.Unmarshal(content, &values)
+	case ".yaml", ".yml":
+		return values, yaml.Unmarshal(content, &values)
+	case ".toml":
+		return values, toml.Unmarshal(content, &values)
+	}
+	return nil, fmt.Errorf("unsupported config format '%s', use .json, .yaml or .toml", ext)
+}
+
+// Validate rejects the settings the node can't run with
+func (c Config) Validate() error {
+	if c.Storage.DataDir == "" {
+		return fmt.Errorf("storage.data_dir is required")
+	}
+	if !c.API.DisableSSL && c.Network.Port != HttpSSLPort {
+		return fmt.Errorf("network.port must be %d with SSL enabled, disable SSL to serve on port %d", HttpSSLPort, c.Network.Port)
+	}
+	if c.Network.Port == 0 {
+		return fmt.Errorf("network.port is required")
+	}
+	if c.Network.Light && c.Mining.Signer {
+		return fmt.Errorf("light nodes can't seal blocks as a signer")
+	}
+	if c.Mining.Interval <= 0 {
+		return fmt.Errorf("mining.interval must be positive")
+	}
+	if c.Mining.MinTxs < 0 || c.Mining.MaxBlockSize < 0 {
+		return fmt.Errorf("mining.min_txs and mining.max_block_size can't be negative")
+	}
+	if c.Peers.BootstrapIP != "" && c.Peers.BootstrapPort == 0 {
+		return fmt.Errorf("peers.bootstrap_port is required with peers.bootstrap_ip")
+	}
+	if !isOneOf(c.Logging.Level, logLevels) {
+		return fmt.Errorf("logging.level must be one of %s, not '%s'", strings.Join(logLevels, ", "), c.Logging.Level)
+	}
+	if !isOneOf(c.Logging.Format, logFormats) {
+		return fmt.Errorf("logging.format must be one of %s, not '%s'", strings.Join(logFormats, ", "), c.Logging.Format)
+	}
+	return nil
+}
+
+// MiningPolicy of the mining section
+func (c Config) MiningPolicy() MiningPolicy {
+	return MiningPolicy{
+		Enabled:          c.Mining.Enabled,
+		AllowEmptyBlocks: c.Mining.AllowEmptyBlocks,
+		MinTxs:           c.Mining.MinTxs,
+		MaxBlockSize:     c.Mining.MaxBlockSize,
+		Interval:         time.Duration(c.Mining.Interval),
+	}
+}
+
+// Encode the config as JSON, YAML or TOML
+func (c Config) Encode(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(c, "", "  ")
+	case "yaml", "yml":
+		return yaml.Marshal(c)
+	case "toml":
+		var out bytes.Buffer
+		err := toml.NewEncoder(&out).Encode(c)
+		return out.Bytes(), err
+	}
+	return nil, fmt.Errorf("unsupported config format '%s', use json, yaml or toml", format)
+}
+
+// forEachConfigKey calls fn with the settable value of every key of every section
+func (c *Config) forEachConfigKey(fn func(section, key string, v reflect.Value) error) error {
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		sectionName := sections.Type().Field(i).Tag.Get("json")
+
+		for j := 0; j < section.NumField(); j++ {
+			err := fn(sectionName, section.Type().Field(j).Tag.Get("json"), section.Field(j))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyEnv overrides the keys set in the env, lists are comma separated
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	return c.forEachConfigKey(func(section, key string, v reflect.Value) error {
+		name := strings.ToUpper(ConfigEnvPrefix + "_" + section + "_" + key)
+		raw, ok := lookup(name)
+		if !ok {
+			return nil
+		}
+
+		err := setConfigValue(v, raw)
+		if err != nil {
+			return fmt.Errorf("invalid env var %s='%s'. %s", name, raw, err.Error())
+		}
+		return nil
+	})
+}
+
+func setConfigValue(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(Duration(0)) {
+		return v.Addr().Interface().(*Duration).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Slice:
+		items := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config value kind %s", v.Kind())
+	}
+	return nil
+}
+
+func isOneOf(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	expected := DefaultConfig()
+	expected.Network.Port = 8080
+	expected.Mining.Enabled = true
+	expected.Mining.Interval = Duration(5 * time.Second)
+	expected.Storage.DataDir = "/var/lib/nemos"
+	expected.API.DisableSSL = true
+	expected.Peers.Bootstraps = []string{"127.0.0.1:8081", "127.0.0.1:8082"}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"config.json", `{
+			"network": {"port": 8080},
+			"mining": {"enabled": true, "interval": "5s"},
+			"storage": {"data_dir": "/var/lib/nemos"},
+			"api": {"disable_ssl": true},
+			"peers": {"bootstraps": ["127.0.0.1:8081", "127.0.0.1:8082"]}
+		}`},
+		{"config.yaml", `
+network:
+  port: 8080
+mining:
+  enabled: true
+  interval: 5s
+storage:
+  data_dir: /var/lib/nemos
+api:
+  disable_ssl: true
+peers:
+  bootstraps: ["127.0.0.1:8081", "127.0.0.1:8082"]
+`},
+		{"config.toml", `
+# Overrides of the defaults
+[network]
+port = 8080
+
+[mining]
+enabled = true
+interval = "5s"
+
+[storage]
+data_dir = "/var/lib/nemos"
+
+[api]
+disable_ssl = true
+
+[peers]
+bootstraps = ["127.0.0.1:8081", "127.0.0.1:8082"]
+`},
+	}
+
+	for _, test := range tests {
+		cfg, err := LoadConfig(writeTestConfig(t, test.name, test.content))
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(cfg, expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, expected, cfg)
+		}
+	}
+}
+
+func TestLoadConfigInvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"config.json", `{"network": {"prt": 8080}}`},
+		{"config.yaml", "network:\n  port: not a port\n"},
+		{"config.toml", "[mining]\ninterval = \"soon\"\n"},
+		{"config.toml", "[network\nport = 8080\n"},
+		{"config.ini", "port = 8080\n"},
+	}
+
+	for _, test := range tests {
+		_, err := LoadConfig(writeTestConfig(t, test.name, test.content))
+		if err == nil {
+			t.Errorf("%s: expected %q to be rejected", test.name, test.content)
+		}
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeTestConfig(t, "config.toml", `
+[network]
+ip = "10.0.0.1"
+port = 8080
+
+[logging]
+level = "debug"
+`)
+
+	t.Setenv("NEMOS_NETWORK_PORT", "9090")
+	t.Setenv("NEMOS_PEERS_BOOTSTRAPS", "127.0.0.1:8081, ,127.0.0.1:8082")
+	t.Setenv("NEMOS_STORAGE_DATA_DIR", "~/.nemos")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key      string
+		value    interface{}
+		expected interface{}
+	}{
+		{"network.ip from the file", cfg.Network.IP, "10.0.0.1"},
+		{"network.port from the env", cfg.Network.Port, uint64(9090)},
+		{"logging.level from the file", cfg.Logging.Level, "debug"},
+		{"logging.format from the defaults", cfg.Logging.Format, "text"},
+		{"peers.bootstraps from the env", cfg.Peers.Bootstraps, []string{"127.0.0.1:8081", "127.0.0.1:8082"}},
+		{"storage.data_dir expanded", cfg.Storage.DataDir, filepath.Join(home, ".nemos")},
+	}
+
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.value, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.key, test.expected, test.value)
+		}
+	}
+
+	t.Setenv("NEMOS_MINING_MIN_TXS", "many")
+	_, err = LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "NEMOS_MINING_MIN_TXS") {
+		t.Errorf("expected the invalid env var to be rejected, got %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := func() Config {
+		cfg := DefaultConfig()
+		cfg.Storage.DataDir = "/var/lib/nemos"
+		return cfg
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		valid  bool
+	}{
+		{"defaults with a data dir", func(cfg *Config) {}, true},
+		{"no data dir", func(cfg *Config) { cfg.Storage.DataDir = "" }, false},
+		{"custom port with SSL", func(cfg *Config) { cfg.Network.Port = 8080 }, false},
+		{"custom port without SSL", func(cfg *Config) { cfg.Network.Port = 8080; cfg.API.DisableSSL = true }, true},
+		{"no port", func(cfg *Config) { cfg.Network.Port = 0; cfg.API.DisableSSL = true }, false},
+		{"light signer", func(cfg *Config) { cfg.Network.Light = true; cfg.Mining.Signer = true }, false},
+		{"no mining interval", func(cfg *Config) { cfg.Mining.Interval = 0 }, false},
+		{"negative min txs", func(cfg *Config) { cfg.Mining.MinTxs = -1 }, false},
+		{"negative max block size", func(cfg *Config) { cfg.Mining.MaxBlockSize = -1 }, false},
+		{"bootstrap without port", func(cfg *Config) { cfg.Peers.BootstrapPort = 0 }, false},
+		{"no bootstrap", func(cfg *Config) { cfg.Peers.BootstrapIP = ""; cfg.Peers.BootstrapPort = 0 }, true},
+		{"unknown log level", func(cfg *Config) { cfg.Logging.Level = "trace" }, false},
+		{"unknown log format", func(cfg *Config) { cfg.Logging.Format = "xml" }, false},
+	}
+
+	for _, test := range tests {
+		cfg := valid()
+		test.modify(&cfg)
+
+		err := cfg.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected valid, got %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func TestConfigEncode(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Storage.DataDir = "/var/lib/nemos"
+	cfg.Mining.Interval = Duration(90 * time.Second)
+	cfg.Peers.Bootstraps = []string{"127.0.0.1:8081"}
+
+	for _, format := range []string{"json", "yaml", "toml"} {
+		content, err := cfg.Encode(format)
+		if err != nil {
+			t.Errorf("%s: %s", format, err)
+			continue
+		}
+
+		loaded, err := LoadConfig(writeTestConfig(t, "config."+format, string(content)))
+		if err != nil {
+			t.Errorf("%s: %s", format, err)
+			continue
+		}
+		if !reflect.DeepEqual(loaded, cfg) {
+			t.Errorf("%s: expected %+v, got %+v", format, cfg, loaded)
+		}
+	}
+
+	_, err := cfg.Encode("ini")
+	if err == nil {
+		t.Errorf("expected the unsupported format to be rejected")
+	}
+}
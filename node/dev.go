@@ -0,0 +1,60 @@
+package node
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/irononet/nemos/core"
+	"github.com/irononet/nemos/wallet"
+)
+
+// The dev account key is public, it only ever funds throw-away development chains
+const DevPrivateKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+const DevAccountPassword = "dev"
+const DevAccountBalance = 1000000000
+const DevPort = 8080
+const DevMiningDifficulty = 0
+
+func DevAccount() common.Address {
+	// The constant key always parses
+	key, _ := crypto.HexToECDSA(DevPrivateKey)
+	return crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// DevMiningPolicy mines a block as soon as a tx enters the mempool
+var DevMiningPolicy = MiningPolicy{
+	Enabled:     true,
+	MinTxs:      1,
+	Interval:    time.Second,
+	InstantSeal: true,
+}
+
+// InitDevDataDir writes a genesis prefunding the dev account and imports the dev key
+// in the keystore, unlocked by DevAccountPassword
+func InitDevDataDir(dataDir string) error {
+	genesis, err := json.Marshal(map[string]interface{}{
+		"genesis_time": time.Now().UTC().Format(time.RFC3339Nano),
+		"chain_id":     "nemos-dev",
+		"symbol":       "NEM",
+		"balances":     map[common.Address]uint{DevAccount(): DevAccountBalance},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = core.InitDataDirIfNotExists(dataDir, genesis)
+	if err != nil {
+		return err
+	}
+
+	key, err := crypto.HexToECDSA(DevPrivateKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = wallet.ImportKeystoreAccount(dataDir, key, DevAccountPassword)
+	return err
+}
@@ -0,0 +1,87 @@
+package node
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+
+	"github.com/irononet/nemos/core"
+)
+
+var ErrBadRequest = errors.New("bad request")
+var ErrForbidden = errors.New("forbidden")
+
+const errCodeInternal = "internal_error"
+
+type errCode struct {
+	err    error
+	code   string
+	status int
+}
+
+// errCodes maps the errors to the machine readable codes and HTTP statuses
+// returned in ErrRes, so callers and peers can tell the failures apart
+var errCodes = []errCode{
+	{ErrBadRequest, "bad_request", http.StatusBadRequest},
+	{ErrForbidden, "forbidden", http.StatusForbidden},
+	{core.ErrNotFound, "not_found", http.StatusNotFound},
+	{core.ErrInvalidSignature, "invalid_signature", http.StatusBadRequest},
+	{core.ErrInvalidNonce, "invalid_nonce", http.StatusConflict},
+	{core.ErrInsufficientGas, "insufficient_gas", http.StatusUnprocessableEntity},
+	{core.ErrInsufficientBalance, "insufficient_balance", http.StatusUnprocessableEntity},
+	{core.ErrFeeTooLow, "fee_too_low", http.StatusUnprocessableEntity},
+	{core.ErrInvalidToken, "invalid_token", http.StatusUnprocessableEntity},
+	{core.ErrUnknownTxType, "unknown_tx_type", http.StatusBadRequest},
+	{core.ErrInvalidPayload, "invalid_payload", http.StatusBadRequest},
+	{core.ErrLocked, "locked", http.StatusConflict},
+	{core.ErrContractFailed, "contract_failed", http.StatusUnprocessableEntity},
+	{core.ErrOutOfGas, "out_of_gas", http.StatusUnprocessableEntity},
+	{core.ErrNotValidator, "not_validator", http.StatusForbidden},
+	{core.ErrBadBlockNumber, "bad_block_number", http.StatusUnprocessableEntity},
+	{core.ErrBadParent, "bad_parent", http.StatusUnprocessableEntity},
+	{core.ErrInvalidPoW, "invalid_pow", http.StatusUnprocessableEntity},
+	{core.ErrBadBlockHash, "bad_block_hash", http.StatusUnprocessableEntity},
+	{core.ErrBadTxRoot, "bad_tx_root", http.StatusUnprocessableEntity},
+	{core.ErrBadStateRoot, "bad_state_root", http.StatusUnprocessableEntity},
+	{core.ErrBadTimestamp, "bad_timestamp", http.StatusUnprocessableEntity},
+	{core.ErrBlockFromFuture, "block_from_future", http.StatusUnprocessableEntity},
+	{core.ErrBadCoinbase, "bad_coinbase", http.StatusUnprocessableEntity},
+	{core.ErrBadBaseFee, "bad_base_fee", http.StatusUnprocessableEntity},
+	{core.ErrGasLimitExceeded, "gas_limit_exceeded", http.StatusUnprocessableEntity},
+	{core.ErrBadSeal, "bad_seal", http.StatusUnprocessableEntity},
+	{core.ErrBadValidators, "bad_validators", http.StatusUnprocessableEntity},
+	{core.ErrLegacyBlock, "legacy_block", http.StatusUnprocessableEntity},
+	{core.ErrFinalized, "finalized", http.StatusConflict},
+	{keystore.ErrDecrypt, "invalid_password", http.StatusUnauthorized},
+	{accounts.ErrUnknownAccount, "unknown_account", http.StatusNotFound},
+}
+
+func errCodeOf(err error) (string, int) {
+	for _, c := range errCodes {
+		if errors.Is(err, c.err) {
+			return c.code, c.status
+		}
+	}
+	return errCodeInternal, http.StatusInternalServerError
+}
+
+// remoteErr is an error returned by a peer, it unwraps to the error matching its code
+type remoteErr struct {
+	msg  string
+	code string
+}
+
+func (e remoteErr) Error() string {
+	return e.msg
+}
+
+func (e remoteErr) Unwrap() error {
+	for _, c := range errCodes {
+		if c.code == e.code {
+			return c.err
+		}
+	}
+	return nil
+}
@@ -8,9 +8,11 @@ import (
 )
 
 func writeErrRes(w http.ResponseWriter, err error){
-	jsonErrRes, _ := json.Marshal(ErrRes{err.Error()}) 
+	code, status := errCodeOf(err) 
+
+	jsonErrRes, _ := json.Marshal(ErrRes{err.Error(), code}) 
 	w.Header().Set("Content-Type", "application/json") 
-	w.WriteHeader(http.StatusInternalServerError) 
+	w.WriteHeader(status) 
 	w.Write(jsonErrRes) 
 }
 
@@ -29,13 +31,13 @@ func writeRes(w http.ResponseWriter, content interface{}){
 func readReq(r *http.Request, reqBody interface{}) error{
 	reqBodyJson, err := ioutil.ReadAll(r.Body) 
 	if err != nil{
-		return fmt.Errorf("unable to read request body. %s", err.Error()) 
+		return fmt.Errorf("%w: unable to read request body. %s", ErrBadRequest, err.Error()) 
 	}
 	defer r.Body.Close() 
 
 	err = json.Unmarshal(reqBodyJson, reqBody) 
 	if err != nil{
-		return fmt.Errorf("unable to unmarshal request body. %s", err.Error()) 
+		return fmt.Errorf("%w: unable to unmarshal request body. %s", ErrBadRequest, err.Error()) 
 	}
 	return nil 
 }
@@ -48,6 +50,10 @@ func readRes(r *http.Response, reqBody interface{}) error{
 	defer r.Body.Close() 
 
 	if r.StatusCode != http.StatusOK{
+		errRes := ErrRes{} 
+		if json.Unmarshal(resBodyJson, &errRes) == nil && errRes.Code != ""{
+			return fmt.Errorf("unable to process response. %w", remoteErr{errRes.Error, errRes.Code}) 
+		}
 		return fmt.Errorf("unable to process response. %s", string(resBodyJson)) 
 	}
 
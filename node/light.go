@@ -0,0 +1,268 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/irononet/nemos/core"
+)
+
+type LightBalanceRes struct {
+	Hash    core.Hash      `json:"block_hash"`
+	Number  uint64         `json:"block_number"`
+	Account common.Address `json:"account"`
+	Balance uint           `json:"balance"`
+	Nonce   uint           `json:"nonce"`
+}
+
+type LightTxRes struct {
+	Hash   core.Hash     `json:"block_hash"`
+	Number uint64        `json:"block_number"`
+	Tx     core.SignedTx `json:"tx"`
+}
+
+func (n *Node) runLight(ctx context.Context, isSSLDisabled bool, sslEmail string) error {
+	headers, err := core.NewHeaderChainFromDisk(n.dataDir, n.miningDifficulty)
+	if err != nil {
+		return err
+	}
+
+	defer headers.Close()
+
+	n.headers = headers
+
+	err = n.loadPeers()
+	if err != nil {
+		return err
+	}
+	defer n.savePeers()
+
+	latest, _ := n.headers.Latest()
+	componentLogger(logComponentNode).Info("light client headers chain", "height", latest.Value.Number, "hash", latest.Key.Hex())
+
+	stopSync := goUntilStopped(n.sync)
+
+	err = n.serveHttp(ctx, isSSLDisabled, sslEmail)
+
+	componentLogger(logComponentNode).Info("shutting down")
+	stopSync()
+
+	return err
+}
+
+func (n *Node) registerLightHandlers(handler *http.ServeMux) {
+	handler.HandleFunc(endpointStatus, func(w http.ResponseWriter, r *http.Request) {
+		lightStatusHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointAddPeer, func(w http.ResponseWriter, r *http.Request) {
+		addPeerHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointLightBalance, func(w http.ResponseWriter, r *http.Request) {
+		lightBalanceHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointLightTx, func(w http.ResponseWriter, r *http.Request) {
+		lightTxHandler(w, r, n)
+	})
+}
+
+// syncLightHeaders follows the header chain of the highest full peer
+func (n *Node) syncLightHeaders(ctx context.Context, peers map[string]PeerNode, statuses map[string]StatusRes) error {
+	bestAddr := ""
+	for addr, status := range statuses {
+		if status.Hash.IsEmpty() || status.IsLight {
+			continue
+		}
+		if bestAddr == "" || status.Number > statuses[bestAddr].Number {
+			bestAddr = addr
+		}
+	}
+
+	if bestAddr == "" {
+		return nil
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		tip, hasTip := n.headers.Latest()
+		if hasTip && statuses[bestAddr].Number <= tip.Value.Number {
+			return nil
+		}
+
+		headers, err := fetchHeadersFromPeer(ctx, peers[bestAddr], tip.Key, syncHeadersBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(headers) == 0 {
+			return nil
+		}
+
+		err = n.headers.AddHeaders(headers)
+		if err != nil {
+			return fmt.Errorf("invalid headers from peer '%s'. %s", bestAddr, err.Error())
+		}
+
+		componentLogger(logComponentSync).Info("synced headers", "height", headers[len(headers)-1].Value.Number, "peer", bestAddr)
+	}
+}
+
+// lightProofMaxDepth is how many blocks behind the synced tip an account proof can be,
+// older proofs may hide the account changes made since
+const lightProofMaxDepth = 2
+
+// fetchAccountProof asks the known peers for the account state until one
+// provides a proof matching a recent synced header
+func (n *Node) fetchAccountProof(ctx context.Context, account common.Address) (core.AccountProof, core.BlockHeader, error) {
+	err := fmt.Errorf("no full peer to query")
+
+	for _, peer := range n.knownPeers {
+		url := fmt.Sprintf(
+			"%s://%s%s?%s=%s",
+			peer.ApiProtocol(),
+			peer.TcpAddress(),
+			endpointAccountProof,
+			endpointProofQueryKeyAccount,
+			account.Hex(),
+		)
+
+		proof := core.AccountProof{}
+		err = getJson(ctx, url, &proof)
+		if err != nil {
+			continue
+		}
+
+		header, ok := n.headers.GetByHash(proof.BlockHash)
+		if !ok {
+			err = fmt.Errorf("block '%s' isn't synced yet", proof.BlockHash.Hex())
+			continue
+		}
+
+		latest, _ := n.headers.Latest()
+		if latest.Value.Number > header.Number+lightProofMaxDepth {
+			err = fmt.Errorf("proof of block '%d' is more than %d blocks behind the synced height '%d'", header.Number, lightProofMaxDepth, latest.Value.Number)
+			continue
+		}
+
+		err = core.VerifyAccountProof(header, proof)
+		if err != nil {
+			continue
+		}
+
+		return proof, header, nil
+	}
+
+	return core.AccountProof{}, core.BlockHeader{}, err
+}
+
+// fetchTxProof asks the known peers for the tx until one provides an
+// inclusion proof matching a synced header
+func (n *Node) fetchTxProof(ctx context.Context, txHash core.Hash) (core.TxProof, error) {
+	err := fmt.Errorf("no full peer to query")
+
+	for _, peer := range n.knownPeers {
+		url := fmt.Sprintf(
+			"%s://%s%s?%s=%s",
+			peer.ApiProtocol(),
+			peer.TcpAddress(),
+			endpointTxProof,
+			endpointProofQueryKeyHash,
+			txHash.Hex(),
+		)
+
+		proof := core.TxProof{}
+		err = getJson(ctx, url, &proof)
+		if err != nil {
+			continue
+		}
+
+		header, ok := n.headers.GetByHash(proof.BlockHash)
+		if !ok {
+			err = fmt.Errorf("block '%s' isn't synced yet", proof.BlockHash.Hex())
+			continue
+		}
+
+		err = core.VerifyTxProof(header, txHash, proof)
+		if err != nil {
+			continue
+		}
+
+		return proof, nil
+	}
+
+	return core.TxProof{}, err
+}
+
+func getJson(ctx context.Context, url string, content interface{}) error {
+	res, err := getFromPeer(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	return readRes(res, content)
+}
+
+func lightStatusHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	latest, _ := node.headers.Latest()
+
+	res := StatusRes{
+		Hash:        latest.Key,
+		Number:      latest.Value.Number,
+		KnownPeers:  node.knownPeers,
+		PendingTxs:  []core.SignedTx{},
+		NodeVersion: node.nodeVersion,
+		Account:     core.NewAccount(node.info.Account.String()),
+		IsLight:     true,
+	}
+
+	writeRes(w, res)
+}
+
+func lightBalanceHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	account := core.NewAccount(r.URL.Query().Get(endpointProofQueryKeyAccount))
+
+	proof, header, err := node.fetchAccountProof(r.Context(), account)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, LightBalanceRes{
+		Hash:    proof.BlockHash,
+		Number:  header.Number,
+		Account: proof.State.Account,
+		Balance: proof.State.Balance,
+		Nonce:   proof.State.Nonce,
+	})
+}
+
+func lightTxHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	hash := core.Hash{}
+	err := hash.UnmarshalText([]byte(r.URL.Query().Get(endpointProofQueryKeyHash)))
+	if err != nil {
+		writeErrRes(w, fmt.Errorf("%w: invalid tx hash. %s", ErrBadRequest, err.Error()))
+		return
+	}
+
+	proof, err := node.fetchTxProof(r.Context(), hash)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, LightTxRes{proof.BlockHash, proof.BlockNumber, proof.Tx})
+}
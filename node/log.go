@@ -0,0 +1,78 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const logKeyComponent = "component"
+
+const (
+	logComponentSync    = "sync"
+	logComponentMiner   = "miner"
+	logComponentMempool = "mempool"
+	logComponentHTTP    = "http"
+	logComponentNode    = "node"
+)
+
+// NewLogger writes the records at or above the configured level as text or JSON lines
+func NewLogger(w io.Writer, cfg LoggingConfig) (*slog.Logger, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(cfg.Level))
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level '%s'. %s", cfg.Level, err.Error())
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch cfg.Format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text", "":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format '%s'", cfg.Format)
+}
+
+// componentLogger tags the records with the node component. It wraps slog.Default
+// on every call so the logger set up by `nemos run` applies.
+func componentLogger(component string) *slog.Logger {
+	return slog.Default().With(logKeyComponent, component)
+}
+
+// statusRecorder keeps the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs every request at debug level and the failed ones as warnings
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{w, http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		level := slog.LevelDebug
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+
+		componentLogger(logComponentHTTP).Log(r.Context(), level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"remote", r.RemoteAddr,
+			"duration", time.Since(start),
+		)
+	})
+}
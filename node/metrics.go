@@ -0,0 +1,91 @@
+package node
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const endpointMetrics = "/metrics"
+
+const (
+	miningResultMined     = "mined"
+	miningResultCancelled = "cancelled"
+	miningResultFailed    = "failed"
+)
+
+// The node metrics are served in the Prometheus text format along with the chain metrics of core
+var (
+	mempoolSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "nemos_mempool_size",
+		Help: "Pending txs waiting to be mined.",
+	})
+	mempoolTxsAccepted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nemos_mempool_txs_accepted_total",
+		Help: "Txs added to the mempool, submitted or synced from peers.",
+	})
+	mempoolTxsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "nemos_mempool_txs_rejected_total",
+		Help: "Txs refused by the mempool by error code.",
+	}, []string{"reason"})
+	peersCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "nemos_peers",
+		Help: "Known peers.",
+	})
+	syncLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "nemos_sync_lag_blocks",
+		Help: "Blocks a peer is ahead of the node at the last sync, 0 when the node is not behind.",
+	}, []string{"peer"})
+	miningAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "nemos_mining_attempts_total",
+		Help: "Blocks the node tried to seal by result.",
+	}, []string{"result"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "nemos_http_request_duration_seconds",
+		Help:    "Latency of the HTTP API requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+)
+
+// observeSyncLag records how far each queried peer is ahead of the local height.
+// The peers that weren't reachable are dropped from the metric.
+func observeSyncLag(height uint64, statuses map[string]StatusRes) {
+	syncLag.Reset()
+	for addr, status := range statuses {
+		lag := uint64(0)
+		if status.Number > height {
+			lag = status.Number - height
+		}
+		syncLag.WithLabelValues(addr).Set(float64(lag))
+	}
+}
+
+func observeTxRejected(err error) {
+	code, _ := errCodeOf(err)
+	mempoolTxsRejected.WithLabelValues(code).Inc()
+}
+
+// measureRequests observes the latency of every request. The path label is the pattern of
+// the handler so /block/{hash} requests share one series.
+func measureRequests(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{w, http.StatusOK}
+
+		mux.ServeHTTP(rec, r)
+
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(r.Method, pattern, strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+func registerMetricsHandler(handler *http.ServeMux) {
+	handler.Handle(endpointMetrics, promhttp.Handler())
+}
@@ -2,8 +2,8 @@ package node
 
 import (
 	"context" 
+	"errors" 
 	"fmt" 
-	"math/rand" 
 	"time" 
 
 	"github.com/ethereum/go-ethereum/common" 
@@ -16,61 +16,117 @@ type PendingBlock struct{
 	time uint64 
 	miner common.Address 
 	txs []core.SignedTx
+	stateRoot core.Hash 
+	reward uint 
+	baseFee uint 
+	validators []common.Address 
 }
 
 func NewPendingBlock(parent core.Hash, number uint64, miner common.Address, txs []core.SignedTx) PendingBlock{
-	return PendingBlock{parent, number, uint64(time.Now().Unix()), miner, txs}
+	return PendingBlock{parent, number, uint64(time.Now().Unix()), miner, txs, core.Hash{}, core.BlockReward, core.InitialBaseFee, nil}
 }
 
-func Mine(ctx context.Context, pb PendingBlock, miningDifficulty uint) (core.Block, error){
-	if len(pb.txs) == 0{
-		return core.Block{}, fmt.Errorf("mining empty blocks is not allowed") 
-	}
+// Block returns the unsealed block, without nonce, starting with the coinbase tx
+func (pb PendingBlock) Block() core.Block{
+	reward := pb.reward + core.Block{Header: core.BlockHeader{BaseFee: pb.baseFee}, Txs: pb.txs}.GasReward() 
+	coinbase := core.NewCoinbaseTx(pb.miner, pb.number, pb.time, reward) 
 
-	start := time.Now() 
-	attempt := 0 
-	var block core.Block 
-	var hash core.Hash 
-	var nonce uint32 
-
-	for !core.IsBlockHashValid(hash, miningDifficulty){
-		select{
-		case <-ctx.Done(): 
-			fmt.Println("mining cancelled!") 
-			return core.Block{}, fmt.Errorf("mining cancelled. %s", ctx.Err()) 
-		default: 
-		}
+	txs := append([]core.SignedTx{coinbase}, pb.txs...) 
 
-		attempt++ 
-		nonce = generateNonce() 
+	block := core.NewBlock(pb.parent, pb.number, 0, pb.time, pb.miner, txs) 
+	block.Header.StateRoot = pb.stateRoot 
+	block.Header.BaseFee = pb.baseFee 
+	block.Header.Validators = pb.validators 
 
-		if attempt%1000000 == 0 || attempt ==  1{
-			fmt.Printf("mining %d pending Txs. Attempt: %d\n", len(pb.txs), attempt) 
-		}
-		block = core.NewBlock(pb.parent, pb.number, nonce, pb.time, pb.miner, pb.txs) 
-		blockHash, err := block.Hash() 
+	return block
+}
+
+// MiningPolicy decides when the node mines and how many of the pending txs go in a block
+type MiningPolicy struct{
+	Enabled bool 
+	// Empty blocks are mined every Interval, moving the chain time forward without txs 
+	AllowEmptyBlocks bool 
+	// Pending txs required to mine a block when empty blocks aren't allowed 
+	MinTxs int 
+	// Encoded size limit of the block txs in bytes, 0 leaves the block gas limit as the only limit 
+	MaxBlockSize int 
+	Interval time.Duration 
+	// Mine as soon as a tx enters the mempool instead of waiting for the interval 
+	InstantSeal bool 
+}
+
+var DefaultMiningPolicy = MiningPolicy{
+	Enabled: true, 
+	AllowEmptyBlocks: false, 
+	MinTxs: 1, 
+	MaxBlockSize: 0, 
+	Interval: miningIntervalSeconds * time.Second, 
+}
+
+// shouldMine reports whether a block of the given number of txs can be mined
+func (p MiningPolicy) shouldMine(txs int) bool{
+	if !p.Enabled{
+		return false 
+	}
+	if p.AllowEmptyBlocks{
+		return true 
+	}
+	return txs > 0 && txs >= p.MinTxs 
+}
+
+// limitSize keeps the txs, in order, fitting in MaxBlockSize
+func (p MiningPolicy) limitSize(txs []core.SignedTx) []core.SignedTx{
+	if p.MaxBlockSize <= 0{
+		return txs 
+	}
+
+	size := 0 
+	for i, tx := range txs{
+		txJson, err := tx.Encode() 
 		if err != nil{
-			return core.Block{}, fmt.Errorf("couldn't mine block. %s", err.Error()) 
+			return txs[:i] 
 		}
 
-		hash = blockHash
+		size += len(txJson) 
+		if size > p.MaxBlockSize{
+			return txs[:i]
+		}
 	}
+	return txs 
+}
 
-	fmt.Printf("\nMined new block '%x' using Pow \n", hash) 
-	fmt.Printf("\tHeight: '%v'\n", block.Header.Number) 
-	fmt.Printf("\tNonce: '%v'\n", block.Header.Nonce) 
-	fmt.Printf("\tCreated: '%v'\n", block.Header.Time) 
-	fmt.Printf("\tMiner: '%v'\n", block.Header.Miner.String()) 
-	fmt.Printf("\tParent: '%v'\n\n", block.Header.Parent.Hex()) 
+// Mine seals the pending block with the consensus engine of the chain, 
+// whether empty blocks are mined is up to the MiningPolicy
+func Mine(ctx context.Context, pb PendingBlock, engine core.Engine) (core.Block, error){
+	start := time.Now() 
 
-	fmt.Printf("\tAttempt: '%v'\n", attempt) 
-	fmt.Printf("\tTime: %s\n\n", time.Since(start)) 
+	// The roots are computed once, the engine only completes the header 
+	block, err := engine.Seal(ctx, pb.Block()) 
+	if errors.Is(err, context.Canceled){
+		miningAttempts.WithLabelValues(miningResultCancelled).Inc() 
+		return core.Block{}, err 
+	}
+	if err != nil{
+		miningAttempts.WithLabelValues(miningResultFailed).Inc() 
+		return core.Block{}, err 
+	}
+	miningAttempts.WithLabelValues(miningResultMined).Inc() 
 
-	return block, nil 
-}
+	hash, err := block.Hash() 
+	if err != nil{
+		return core.Block{}, fmt.Errorf("couldn't mine block. %s", err.Error()) 
+	}
 
-func generateNonce() uint32{
-	rand.Seed(time.Now().UTC().UnixNano()) 
+	componentLogger(logComponentMiner).Info("mined new block", 
+		"hash", hash.Hex(), 
+		"height", block.Header.Number, 
+		"nonce", block.Header.Nonce, 
+		"created", block.Header.Time, 
+		"miner", block.Header.Miner.Hex(), 
+		"parent", block.Header.Parent.Hex(), 
+		"txs", len(block.Txs), 
+		"duration", time.Since(start), 
+	) 
 
-	return rand.Uint32()
-}
\ No newline at end of file
+	return block, nil 
+}
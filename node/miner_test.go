@@ -0,0 +1,64 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/irononet/nemos/core"
+)
+
+// Run with -race: the mining state is shared by the mining loop and the attempts it starts
+func TestStopMiningWhileSealing(t *testing.T) {
+	dataDir := t.TempDir()
+
+	// No block can be sealed at this difficulty, every attempt runs until cancelled
+	const difficulty = 20
+	state, err := core.NewStateFromDisk(dataDir, difficulty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+
+	n := New(dataDir, "127.0.0.1", 8085, core.NewAccount("0x01"), nil, "", difficulty)
+	n.state = state
+	pendingState := state.Copy()
+	n.pendingState = &pendingState
+
+	n.ChangeMiningPolicy(MiningPolicy{
+		Enabled:          true,
+		AllowEmptyBlocks: true,
+		Interval:         5 * time.Millisecond,
+		InstantSeal:      true,
+	})
+
+	stop := goUntilStopped(n.mine)
+
+	for i := 0; i < 20; i++ {
+		n.StartMining()
+		n.newPendingTxs <- core.SignedTx{}
+		time.Sleep(20 * time.Millisecond)
+
+		n.StopMining()
+		n.newPendingTxs <- core.SignedTx{}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	n.StartMining()
+	time.Sleep(20 * time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the seal in flight to be cancelled on shutdown")
+	}
+
+	if !n.state.LatestBlockHash().IsEmpty() {
+		t.Errorf("expected no block to be sealed")
+	}
+}
@@ -2,15 +2,19 @@ package node
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/caddyserver/certmagic"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/irononet/nemos/core"
+	"github.com/irononet/nemos/wallet"
 )
 
 const DefaultBootstrapIp = "node.nemos.chain.root"
@@ -19,10 +23,15 @@ const DefaultBootstrapAcc = "0x09ee50f2f37fcba1845de6fe5c762e83e65e755c"
 const DefaultMiner = "0x00000000000000000000000000000000000000"
 const DefaultIP = "127.0.0.1"
 const HttpSSLPort = 443
+const endpointTxSubmit = "/tx/submit"
+
 const endpointStatus = "/node/status"
 
 const endpointSync = "/node/sync"
 const endpointSyncQueryKeyFromBlock = "fromblock"
+const endpointSyncQueryKeyLimit = "limit"
+
+const endpointHeaders = "/node/headers"
 
 const endpointAddPeer = "/node/peer"
 const endpointAddPeerQueryKeyIP = "ip"
@@ -33,7 +42,55 @@ const endpointAddPeerQueryKeyVersion = "version"
 const endpointBlockByNumberOrHash = "/block/"
 const endpointMempoolViewer = "/mempool"
 
+const endpointAccountProof = "/proof/account"
+const endpointTxProof = "/proof/tx"
+const endpointProofQueryKeyAccount = "account"
+const endpointProofQueryKeyHash = "hash"
+
+const endpointSupply = "/chain/supply"
+
+const endpointFeesEstimate = "/fees/estimate"
+
+const endpointAnchor = "/anchor/"
+
+const endpointContract = "/contract"
+const endpointContractCall = "/contract/call"
+const endpointContractStorage = "/contract/storage"
+const endpointContractQueryKeyAddress = "address"
+const endpointContractQueryKeyKey = "key"
+
+const endpointAccount = "/account"
+const endpointAccountQueryKeyAccount = "account"
+
+const endpointTokensList = "/tokens/list"
+const endpointTokensBalances = "/tokens/balances"
+const endpointTokensCreate = "/tokens/create"
+const endpointTokensQueryKeyAccount = "account"
+
+const endpointMinerStart = "/miner/start"
+const endpointMinerStop = "/miner/stop"
+const endpointMinerMine = "/miner/mine"
+
+// maxMineBlocksPerReq caps the empty blocks mined by a single admin request
+const maxMineBlocksPerReq = 1000
+
+const endpointLightBalance = "/light/balance"
+const endpointLightTx = "/light/tx"
+
 const miningIntervalSeconds = 10
+
+// httpShutdownTimeout bounds the wait for the in-flight requests on shutdown
+const httpShutdownTimeout = 10 * time.Second
+
+// peerRequestTimeout bounds the requests to peers so a stalled one can't hold the sync
+const peerRequestTimeout = 30 * time.Second
+
+// Headers are small so they're fetched in bigger batches than the block bodies.
+// syncMaxPageSize caps what this node serves to others.
+const syncHeadersBatchSize = 500
+const syncBlocksPageSize = 50
+const syncMaxPageSize = 500
+const syncMaxParallelDownloads = 4
 const DefaultMiningDifficulty = 3
 
 type PeerNode struct {
@@ -62,36 +119,64 @@ type Node struct {
 	info    PeerNode
 
 	state *core.State
+	// Serializes the changes to the state and the mempool made by the miner,
+	// the sync and the HTTP API goroutines
+	stateMu sync.Mutex
 
 	pendingState    *core.State
 	knownPeers      map[string]PeerNode
+	peerStats       map[string]PeerStats
 	pendingTxs      map[string]core.SignedTx
 	archivedTx      map[string]core.SignedTx
 	newSyncedBlocks chan core.Block
+	syncCheckpoint  syncCheckpoint
 	newPendingTxs   chan core.SignedTx
 	nodeVersion     string
 
-	miningDifficulty uint
-	isMining         bool
+	// Bumped on every change of pendingTxs, tells whether a block template is still current
+	pendingTxsVersion uint64
+
+	miningDifficulty  uint
+	miningPolicy      MiningPolicy
+	miningPolicyMu    sync.RWMutex
+	miningStopped     chan struct{}
+	maxBlockTimeDrift uint64
+
+	// Block templates handed out to external miners, by work id
+	work   map[core.Hash]pendingWork
+	workMu sync.Mutex
+
+	// Key sealing the blocks of proof of authority chains
+	signerKey *ecdsa.PrivateKey
+
+	// Light nodes only follow the headers and query full peers for proofs
+	isLight bool
+	headers *core.HeaderChain
 }
 
-func New(dataDir string, ip string, port uint64, acc common.Address, bootstrap PeerNode, version string, miningDifficulty uint) *Node {
+func New(dataDir string, ip string, port uint64, acc common.Address, bootstraps []PeerNode, version string, miningDifficulty uint) *Node {
 	knownPeers := make(map[string]PeerNode)
 
 	n := &Node{
-		dataDir:          dataDir,
-		info:             NewPeerNode(ip, port, false, acc, true, version),
-		knownPeers:       knownPeers,
-		pendingTxs:       make(map[string]core.SignedTx),
-		archivedTx:       make(map[string]core.SignedTx),
-		newSyncedBlocks:  make(chan core.Block),
-		newPendingTxs:    make(chan core.SignedTx, 10000),
-		nodeVersion:      version,
-		isMining:         false,
-		miningDifficulty: miningDifficulty,
+		dataDir:           dataDir,
+		info:              NewPeerNode(ip, port, false, acc, true, version),
+		knownPeers:        knownPeers,
+		peerStats:         make(map[string]PeerStats),
+		pendingTxs:        make(map[string]core.SignedTx),
+		archivedTx:        make(map[string]core.SignedTx),
+		newSyncedBlocks:   make(chan core.Block),
+		newPendingTxs:     make(chan core.SignedTx, 10000),
+		nodeVersion:       version,
+		miningPolicy:      DefaultMiningPolicy,
+		miningStopped:     make(chan struct{}, 1),
+		work:              make(map[core.Hash]pendingWork),
+		miningDifficulty:  miningDifficulty,
+		maxBlockTimeDrift: core.DefaultMaxBlockTimeDrift,
 	}
 
-	n.AddPeer(bootstrap)
+	for _, bootstrap := range bootstraps {
+		n.AddPeer(bootstrap)
+	}
 
 	return n
 }
@@ -100,8 +185,28 @@ func NewPeerNode(ip string, port uint64, isBootstrap bool, acc common.Address, c
 	return PeerNode{ip, port, isBootstrap, acc, version, connected}
 }
 
+// AuthorizeSigner makes the node seal proof of authority blocks with the key of its account
+func (n *Node) AuthorizeSigner(key *ecdsa.PrivateKey) error {
+	if crypto.PubkeyToAddress(key.PublicKey) != n.info.Account {
+		return fmt.Errorf("signer key doesn't belong to the node account '%s'", n.info.Account.String())
+	}
+	n.signerKey = key
+
+	return nil
+}
+
+// EnableLightMode makes the node sync and store the block headers only
+func (n *Node) EnableLightMode() {
+	n.isLight = true
+}
+
 func (n *Node) Run(ctx context.Context, isSSLDisabled bool, sslEmail string) error {
-	fmt.Println(fmt.Sprintf("Listening on: %s:%d", n.info.IP, n.info.Port))
+	log := componentLogger(logComponentNode)
+	log.Info("listening", "ip", n.info.IP, "port", n.info.Port, "version", n.info.NodeVersion)
+
+	if n.isLight {
+		return n.runLight(ctx, isSSLDisabled, sslEmail)
+	}
 
 	state, err := core.NewStateFromDisk(n.dataDir, n.miningDifficulty)
 	if err != nil {
@@ -110,19 +215,57 @@ func (n *Node) Run(ctx context.Context, isSSLDisabled bool, sslEmail string) err
 
 	defer state.Close()
 
+	state.ChangeMaxBlockTimeDrift(n.maxBlockTimeDrift)
 	n.state = state
 
+	if poa, isPoA := state.Engine().(*core.PoAEngine); isPoA && n.signerKey != nil {
+		poa.Authorize(n.signerKey)
+	}
+
+	err = n.loadPeers()
+	if err != nil {
+		return err
+	}
+	defer n.savePeers()
+
+	err = n.loadSyncCheckpoint()
+	if err != nil {
+		return err
+	}
+
 	pendingState := state.Copy()
 	n.pendingState = &pendingState
 
-	fmt.Println("Blockchain state:")
-	fmt.Printf(" - height: %d\n", n.state.LatestBlock().Header.Number)
-	fmt.Printf(" - hash: %s\n", n.state.LatestBlockHash().Hex())
+	log.Info("blockchain state", "height", n.state.LatestBlock().Header.Number, "hash", n.state.LatestBlockHash().Hex())
 
-	go n.sync(ctx)
-	go n.mine(ctx)
+	// Stopped once the HTTP server is drained, sync first as it hands the synced blocks
+	// to the miner, so no block is written after the state is closed
+	stopSync := goUntilStopped(n.sync)
+	stopMining := goUntilStopped(n.mine)
 
-	return n.serveHttp(ctx, isSSLDisabled, sslEmail)
+	err = n.serveHttp(ctx, isSSLDisabled, sslEmail)
+
+	log.Info("shutting down")
+	stopSync()
+	stopMining()
+
+	return err
+}
+
+// goUntilStopped runs fn in a goroutine, the returned func cancels it and waits for it to return
+func goUntilStopped(fn func(ctx context.Context) error) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		_ = fn(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
 }
 
 func (n *Node) LatestBlockHash() core.Hash {
@@ -132,6 +275,53 @@ func (n *Node) LatestBlockHash() core.Hash {
 func (n *Node) serveHttp(ctx context.Context, isSSLDisabled bool, sslEmail string) error {
 	handler := http.NewServeMux()
 
+	if n.isLight {
+		n.registerLightHandlers(handler)
+	} else {
+		n.registerHandlers(handler)
+	}
+	registerMetricsHandler(handler)
+
+	server := &http.Server{Addr: fmt.Sprintf(":%d", n.info.Port), Handler: logRequests(measureRequests(handler))}
+
+	if !isSSLDisabled {
+		certmagic.DefaultACME.Email = sslEmail
+
+		// The certificate is issued through the TLS-ALPN challenge on the HTTPS port
+		tlsConfig, err := certmagic.TLS([]string{n.info.IP})
+		if err != nil {
+			return err
+		}
+		tlsConfig.NextProtos = append([]string{"h2", "http/1.1"}, tlsConfig.NextProtos...)
+		server.TLSConfig = tlsConfig
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+
+		// In-flight requests, such as the blocks mined by /miner/mine, complete
+		// before the state is closed
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+
+		shutdownErr <- server.Shutdown(shutdownCtx)
+	}()
+
+	var err error
+	if isSSLDisabled {
+		err = server.ListenAndServe()
+	} else {
+		err = server.ListenAndServeTLS("", "")
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+
+	return <-shutdownErr
+}
+
+func (n *Node) registerHandlers(handler *http.ServeMux) {
 	handler.HandleFunc("/balances/list", func(w http.ResponseWriter, r *http.Request) {
 		listBalanceHandler(w, r, n.state)
 	})
@@ -140,6 +330,10 @@ func (n *Node) serveHttp(ctx context.Context, isSSLDisabled bool, sslEmail strin
 		txAddHandler(w, r, n)
 	})
 
+	handler.HandleFunc(endpointTxSubmit, func(w http.ResponseWriter, r *http.Request) {
+		txSubmitHandler(w, r, n)
+	})
+
 	handler.HandleFunc(endpointStatus, func(w http.ResponseWriter, r *http.Request) {
 		statusHandler(w, r, n)
 	})
@@ -148,6 +342,10 @@ func (n *Node) serveHttp(ctx context.Context, isSSLDisabled bool, sslEmail strin
 		syncHandler(w, r, n)
 	})
 
+	handler.HandleFunc(endpointHeaders, func(w http.ResponseWriter, r *http.Request) {
+		headersHandler(w, r, n)
+	})
+
 	handler.HandleFunc(endpointAddPeer, func(w http.ResponseWriter, r *http.Request) {
 		addPeerHandler(w, r, n)
 	})
@@ -160,75 +358,160 @@ func (n *Node) serveHttp(ctx context.Context, isSSLDisabled bool, sslEmail strin
 		mempoolViewer(w, r, n.pendingTxs)
 	})
 
-	if isSSLDisabled {
-		server := &http.Server{Addr: fmt.Sprintf(":%d", n.info.Port), Handler: handler}
+	handler.HandleFunc(endpointSupply, func(w http.ResponseWriter, r *http.Request) {
+		supplyHandler(w, r, n)
+	})
 
-		go func() {
-			<-ctx.Done()
-			_ = server.Close()
-		}()
+	handler.HandleFunc(endpointFeesEstimate, func(w http.ResponseWriter, r *http.Request) {
+		feesEstimateHandler(w, r, n)
+	})
 
-		err := server.ListenAndServe()
-		if err != http.ErrServerClosed {
-			return err
-		}
+	handler.HandleFunc(endpointAnchor, func(w http.ResponseWriter, r *http.Request) {
+		anchorHandler(w, r, n)
+	})
 
-		return nil
-	} else {
-		certmagic.DefaultACME.Email = sslEmail
+	handler.HandleFunc(endpointContract, func(w http.ResponseWriter, r *http.Request) {
+		contractHandler(w, r, n)
+	})
 
-		return certmagic.HTTPS([]string{n.info.IP}, handler)
-	}
+	handler.HandleFunc(endpointContractCall, func(w http.ResponseWriter, r *http.Request) {
+		contractCallHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointContractStorage, func(w http.ResponseWriter, r *http.Request) {
+		contractStorageHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointAccount, func(w http.ResponseWriter, r *http.Request) {
+		accountHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointTokensList, func(w http.ResponseWriter, r *http.Request) {
+		listTokensHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointTokensBalances, func(w http.ResponseWriter, r *http.Request) {
+		tokenBalancesHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointTokensCreate, func(w http.ResponseWriter, r *http.Request) {
+		tokenCreateHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointMinerStart, func(w http.ResponseWriter, r *http.Request) {
+		minerStartHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointMinerStop, func(w http.ResponseWriter, r *http.Request) {
+		minerStopHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointMinerMine, func(w http.ResponseWriter, r *http.Request) {
+		minerMineHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointMinerWork, func(w http.ResponseWriter, r *http.Request) {
+		minerWorkHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointMinerSubmit, func(w http.ResponseWriter, r *http.Request) {
+		minerSubmitHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointAccountProof, func(w http.ResponseWriter, r *http.Request) {
+		accountProofHandler(w, r, n)
+	})
+
+	handler.HandleFunc(endpointTxProof, func(w http.ResponseWriter, r *http.Request) {
+		txProofHandler(w, r, n)
+	})
 }
 
 func (n *Node) mine(ctx context.Context) error {
-	var miningCtx context.Context
+	// The mining state belongs to this loop: stopCurrentMining is set while an
+	// attempt runs and reset once it reports on miningDone
 	var stopCurrentMining context.CancelFunc
+	miningDone := make(chan struct{}, 1)
 
-	ticker := time.NewTicker(time.Second * miningIntervalSeconds)
+	log := componentLogger(logComponentMiner)
+	ticker := time.NewTicker(n.MiningPolicy().Interval)
+
+	tryMining := func() {
+		if stopCurrentMining != nil || !n.MiningPolicy().shouldMine(n.pendingTxsCount()) {
+			return
+		}
+
+		var miningCtx context.Context
+		miningCtx, stopCurrentMining = context.WithCancel(ctx)
+
+		go func() {
+			err := n.minePendingTxs(miningCtx)
+			// A peer mining the block first cancels the mining
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Error("mining failed", "err", err)
+			}
+			miningDone <- struct{}{}
+		}()
+	}
 
 	for {
 		select {
 		case <-ticker.C:
-			go func() {
-				if len(n.pendingTxs) > 0 && !n.isMining {
-					n.isMining = true
+			tryMining()
 
-					miningCtx, stopCurrentMining = context.WithCancel(ctx)
-					err := n.minePendingTxs(miningCtx)
-					if err != nil {
-						fmt.Errorf("error: %s\n", err)
-					}
+		case <-n.newPendingTxs:
+			if n.MiningPolicy().InstantSeal {
+				tryMining()
+			}
 
-					n.isMining = false
-				}
-			}()
+		case <-miningDone:
+			stopCurrentMining()
+			stopCurrentMining = nil
 
 		case block, _ := <-n.newSyncedBlocks:
-			if n.isMining {
+			if stopCurrentMining != nil {
 				blockHash, _ := block.Hash()
-				fmt.Printf("\nPeer mined next block '%s' faster :(\n", blockHash.Hex())
+				log.Info("peer mined the next block first", "hash", blockHash.Hex(), "height", block.Header.Number)
 
 				n.removeMinedPendingTxs(block)
 				stopCurrentMining()
 			}
 
+		case <-n.miningStopped:
+			if stopCurrentMining != nil {
+				log.Info("mining stopped")
+				stopCurrentMining()
+			}
+
 		case <-ctx.Done():
 			ticker.Stop()
+			// The attempt in progress is cancelled with ctx, wait for it to return
+			if stopCurrentMining != nil {
+				<-miningDone
+			}
 			return nil
 		}
 	}
 }
 
 func (n *Node) minePendingTxs(ctx context.Context) error {
-	blockToMine := NewPendingBlock(
-		n.state.LatestBlockHash(),
-		n.state.NextBlockNumber(),
-		n.info.Account,
-		n.getPendingTXsAsArray(),
-	)
+	// Proof of authority validators wait for their turn, or for the in-turn validator to be late
+	delay, canSeal := n.state.Engine().SealDelay(n.info.Account, n.state.NextBlockNumber(), n.state.Validators)
+	if !canSeal || uint64(time.Now().Unix()) < n.state.LatestBlock().Header.Time+delay {
+		return nil
+	}
+
+	policy := n.MiningPolicy()
+	blockToMine, err := n.newPendingBlock(n.info.Account, n.getPendingTXsAsArray(), policy)
+	if err != nil {
+		return err
+	}
+
+	if !policy.shouldMine(len(blockToMine.txs)) {
+		return nil
+	}
 
-	minedBlock, err := Mine(ctx, blockToMine, n.miningDifficulty)
+	minedBlock, err := Mine(ctx, blockToMine, n.state.Engine())
 	if err != nil {
 		return err
 	}
@@ -243,20 +526,149 @@ func (n *Node) minePendingTxs(ctx context.Context) error {
 	return nil
 }
 
-func (n *Node) removeMinedPendingTxs(block core.Block) {
-	if len(block.Txs) > 0 && len(n.pendingTxs) > 0 {
-		fmt.Println("updating in-memory pending Txs pool:")
+// newPendingBlock assembles the next block out of the txs fitting in it, crediting miner
+func (n *Node) newPendingBlock(miner common.Address, txs []core.SignedTx, policy MiningPolicy) (PendingBlock, error) {
+	n.stateMu.Lock()
+	defer n.stateMu.Unlock()
+
+	blockToMine := NewPendingBlock(
+		n.state.LatestBlockHash(),
+		n.state.NextBlockNumber(),
+		miner,
+		txs,
+	)
+	blockToMine.reward = n.state.BlockRewardAt(blockToMine.number)
+	blockToMine.baseFee = n.state.NextBaseFee()
+
+	// The block time must follow the median time of the last blocks and can't precede its txs
+	if minTime := n.state.MinNextBlockTime(); blockToMine.time < minTime {
+		blockToMine.time = minTime
+	}
+
+	timelyTxs := make([]core.SignedTx, 0, len(blockToMine.txs))
+	for _, tx := range blockToMine.txs {
+		if tx.Time <= blockToMine.time {
+			timelyTxs = append(timelyTxs, tx)
+		}
 	}
+	blockToMine.txs = policy.limitSize(n.state.PackTxs(timelyTxs))
+
+	stateRoot, validators, err := n.state.HeaderAfter(blockToMine.Block())
+	if err != nil {
+		return PendingBlock{}, err
+	}
+	blockToMine.stateRoot = stateRoot
+	blockToMine.validators = validators
+
+	return blockToMine, nil
+}
+
+// MineEmptyBlocks mines count blocks without txs right away, whatever the mining policy
+func (n *Node) MineEmptyBlocks(ctx context.Context, count int) ([]core.Hash, error) {
+	hashes := make([]core.Hash, 0, count)
+
+	for i := 0; i < count; i++ {
+		blockToMine, err := n.newPendingBlock(n.info.Account, nil, n.MiningPolicy())
+		if err != nil {
+			return hashes, err
+		}
+
+		minedBlock, err := Mine(ctx, blockToMine, n.state.Engine())
+		if err != nil {
+			return hashes, err
+		}
+
+		err = n.addBlock(minedBlock)
+		if err != nil {
+			return hashes, err
+		}
+
+		hash, err := minedBlock.Hash()
+		if err != nil {
+			return hashes, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+func (n *Node) pendingTxsCount() int {
+	n.stateMu.Lock()
+	defer n.stateMu.Unlock()
+
+	return len(n.pendingTxs)
+}
+
+// pendingTxsState returns the pending txs along with the mempool version they belong to
+func (n *Node) pendingTxsState() ([]core.SignedTx, uint64) {
+	n.stateMu.Lock()
+	defer n.stateMu.Unlock()
+
+	txs := make([]core.SignedTx, 0, len(n.pendingTxs))
+	for _, tx := range n.pendingTxs {
+		txs = append(txs, tx)
+	}
+	return txs, n.pendingTxsVersion
+}
+
+func (n *Node) removeMinedPendingTxs(block core.Block) {
+	log := componentLogger(logComponentMempool)
+
+	n.stateMu.Lock()
+	defer n.stateMu.Unlock()
 
 	for _, tx := range block.Txs {
 		txHash, _ := tx.Hash()
 		if _, exists := n.pendingTxs[txHash.Hex()]; exists {
-			fmt.Printf("\t-archiving mined TX: %s\n", txHash.Hex())
+			log.Debug("archiving mined tx", "hash", txHash.Hex(), "height", block.Header.Number)
 
 			n.archivedTx[txHash.Hex()] = tx
 			delete(n.pendingTxs, txHash.Hex())
+			n.pendingTxsVersion++
 		}
 	}
+
+	mempoolSize.Set(float64(len(n.pendingTxs)))
+}
+
+// ChangeMiningPolicy sets when the node mines, the interval only applies before Run
+func (n *Node) ChangeMiningPolicy(policy MiningPolicy) {
+	n.miningPolicyMu.Lock()
+	defer n.miningPolicyMu.Unlock()
+
+	if policy.Interval <= 0 {
+		policy.Interval = DefaultMiningPolicy.Interval
+	}
+	n.miningPolicy = policy
+}
+
+func (n *Node) MiningPolicy() MiningPolicy {
+	n.miningPolicyMu.RLock()
+	defer n.miningPolicyMu.RUnlock()
+
+	return n.miningPolicy
+}
+
+// StartMining resumes mining according to the policy
+func (n *Node) StartMining() {
+	n.miningPolicyMu.Lock()
+	defer n.miningPolicyMu.Unlock()
+
+	n.miningPolicy.Enabled = true
+}
+
+// StopMining stops mining new blocks and cancels the block being mined
+func (n *Node) StopMining() {
+	n.miningPolicyMu.Lock()
+	defer n.miningPolicyMu.Unlock()
+
+	n.miningPolicy.Enabled = false
+
+	select {
+	case n.miningStopped <- struct{}{}:
+	default:
+	}
 }
 
 func (n *Node) ChangeMiningDifficulty(newDifficulty uint) {
@@ -264,12 +676,23 @@ func (n *Node) ChangeMiningDifficulty(newDifficulty uint) {
 	n.state.ChangeMiningDifficulty(newDifficulty)
 }
 
+// ChangeMaxBlockTimeDrift sets how many seconds ahead of the node clock a block can be stamped
+func (n *Node) ChangeMaxBlockTimeDrift(seconds uint64) {
+	n.maxBlockTimeDrift = seconds
+	if n.state != nil {
+		n.state.ChangeMaxBlockTimeDrift(seconds)
+	}
+}
+
 func (n *Node) AddPeer(peer PeerNode) {
 	n.knownPeers[peer.TcpAddress()] = peer
+	peersCount.Set(float64(len(n.knownPeers)))
 }
 
 func (n *Node) RemovePeer(peer PeerNode) {
 	delete(n.knownPeers, peer.TcpAddress())
+	delete(n.peerStats, peer.TcpAddress())
+	peersCount.Set(float64(len(n.knownPeers)))
 }
 
 func (n *Node) IsKnwonPeer(peer PeerNode) bool {
@@ -287,22 +710,29 @@ func (n *Node) AddPendingTX(tx core.SignedTx, fromPeer PeerNode) error {
 		return err
 	}
 
-	txJson, err := json.Marshal(tx)
-	if err != nil {
-		return err
-	}
-
+	n.stateMu.Lock()
 	err = n.validateTxBeforeAddingToMempool(tx)
 	if err != nil {
+		n.stateMu.Unlock()
+		observeTxRejected(err)
 		return err
 	}
 
 	_, isAlreadyPending := n.pendingTxs[txHash.Hex()]
 	_, isArchived := n.archivedTx[txHash.Hex()]
+	isNew := !isAlreadyPending && !isArchived
 
-	if !isAlreadyPending && !isArchived {
-		fmt.Printf("Added peding TX %s from Peer %s\n", txJson, fromPeer.TcpAddress())
+	if isNew {
+		componentLogger(logComponentMempool).Info("added pending tx", "hash", txHash.Hex(), "from", tx.From.Hex(), "peer", fromPeer.TcpAddress())
 		n.pendingTxs[txHash.Hex()] = tx
+		n.pendingTxsVersion++
+		mempoolTxsAccepted.Inc()
+		mempoolSize.Set(float64(len(n.pendingTxs)))
+	}
+	n.stateMu.Unlock()
+
+	// Sent without the lock, the miner may be waiting for it to add a block
+	if isNew {
 		n.newPendingTxs <- tx
 	}
 
@@ -310,22 +740,72 @@ func (n *Node) AddPendingTX(tx core.SignedTx, fromPeer PeerNode) error {
 }
 
 func (n *Node) addBlock(block core.Block) error {
-	_, err := n.state.AddBlock(block)
+	n.stateMu.Lock()
+	hash, err := n.state.AddBlock(block)
 	if err != nil {
+		n.stateMu.Unlock()
 		return err
 	}
 
 	pendingState := n.state.Copy()
 	n.pendingState = &pendingState
+	n.dropStaleCheckpoints()
+	n.stateMu.Unlock()
+
+	// The block is added whether or not the signature can be submitted
+	err = n.signCheckpoint(block.Header.Number, hash)
+	if err != nil {
+		componentLogger(logComponentNode).Warn("couldn't sign checkpoint", "height", block.Header.Number, "err", err)
+	}
 
 	return nil
 }
 
+// signCheckpoint submits the signature of the checkpoint block when the node seals as a validator
+func (n *Node) signCheckpoint(number uint64, hash core.Hash) error {
+	if n.signerKey == nil || !n.state.IsCheckpoint(number) || !n.state.IsValidator(n.info.Account) {
+		return nil
+	}
+
+	tx := core.NewCheckpointTx(n.info.Account, number, hash, n.pendingState.GetNextAccountNonce(n.info.Account))
+	signedTx, err := wallet.SignTx(tx, n.signerKey)
+	if err != nil {
+		return err
+	}
+
+	return n.AddPendingTX(signedTx, n.info)
+}
+
+// dropStaleCheckpoints removes the pending checkpoint signatures the chain doesn't accept anymore,
+// e.g. signed while syncing blocks that went on to finalize the checkpoint
+func (n *Node) dropStaleCheckpoints() {
+	for hash, tx := range n.pendingTxs {
+		if tx.Type != core.TxTypeCheckpoint {
+			continue
+		}
+
+		checkpoint := core.Checkpoint{}
+		err := tx.DecodePayload(&checkpoint)
+		if err == nil && n.state.IsCheckpointPending(checkpoint) && !n.state.CheckpointVotes[checkpoint][tx.From] {
+			continue
+		}
+
+		componentLogger(logComponentMempool).Debug("dropping stale checkpoint tx", "hash", hash, "height", checkpoint.Number)
+		delete(n.pendingTxs, hash)
+		n.pendingTxsVersion++
+	}
+
+	mempoolSize.Set(float64(len(n.pendingTxs)))
+}
+
 func (n *Node) validateTxBeforeAddingToMempool(tx core.SignedTx) error {
 	return core.ApplyTx(tx, n.pendingState)
 }
 
 func (n *Node) getPendingTXsAsArray() []core.SignedTx {
+	n.stateMu.Lock()
+	defer n.stateMu.Unlock()
+
 	txs := make([]core.SignedTx, len(n.pendingTxs))
 
 	i := 0
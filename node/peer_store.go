@@ -0,0 +1,171 @@
+package node
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const peersFileName = "peers.json"
+
+// A peer is aged out of the store once it failed this many times in a row
+// and hasn't been reachable for at least peerMaxAge.
+const peerMaxFailures = 5
+const peerMaxAge = 24 * time.Hour
+
+type PeerStats struct {
+	LastSeen  uint64 `json:"last_seen"`
+	Successes uint   `json:"successes"`
+	Failures  uint   `json:"failures"`
+}
+
+type storedPeer struct {
+	PeerNode
+	Stats PeerStats `json:"stats"`
+}
+
+func getPeersFilePath(dataDir string) string {
+	return filepath.Join(dataDir, peersFileName)
+}
+
+// loadPeers restores the peers discovered during previous runs
+func (n *Node) loadPeers() error {
+	content, err := ioutil.ReadFile(getPeersFilePath(n.dataDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var peers []storedPeer
+	err = json.Unmarshal(content, &peers)
+	if err != nil {
+		return fmt.Errorf("unable to load peers store. %s", err.Error())
+	}
+
+	for _, p := range peers {
+		if p.IP == n.info.IP && p.Port == n.info.Port {
+			continue
+		}
+
+		// Peers configured as bootstrap for this run were already added
+		if _, isKnown := n.knownPeers[p.TcpAddress()]; !isKnown {
+			p.IsBootstrap = false
+			p.connected = false
+			n.AddPeer(p.PeerNode)
+		}
+
+		n.peerStats[p.TcpAddress()] = p.Stats
+	}
+
+	return nil
+}
+
+// savePeers persists the known peers and their stats into the data dir
+func (n *Node) savePeers() error {
+	peers := make([]storedPeer, 0, len(n.knownPeers))
+	for addr, peer := range n.knownPeers {
+		peers = append(peers, storedPeer{peer, n.peerStats[addr]})
+	}
+
+	content, err := json.MarshalIndent(peers, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(n.dataDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	tmpPath := getPeersFilePath(n.dataDir) + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, getPeersFilePath(n.dataDir))
+}
+
+func (n *Node) markPeerSeen(peer PeerNode) {
+	stats := n.peerStats[peer.TcpAddress()]
+	stats.LastSeen = uint64(time.Now().Unix())
+	stats.Successes++
+	stats.Failures = 0
+
+	n.peerStats[peer.TcpAddress()] = stats
+}
+
+// markPeerFailed records an unsuccessful contact and removes the peer once
+// it's considered dead. Bootstrap peers are never aged out.
+func (n *Node) markPeerFailed(peer PeerNode) (removed bool) {
+	stats := n.peerStats[peer.TcpAddress()]
+	stats.Failures++
+	n.peerStats[peer.TcpAddress()] = stats
+
+	if peer.IsBootstrap || stats.Failures < peerMaxFailures {
+		return false
+	}
+
+	lastSeen := time.Unix(int64(stats.LastSeen), 0)
+	if time.Since(lastSeen) < peerMaxAge {
+		return false
+	}
+
+	n.RemovePeer(peer)
+	return true
+}
+
+// ParsePeerAddress parses a bootstrap peer given as "ip:port"
+func ParsePeerAddress(addr string) (PeerNode, error) {
+	host, portRaw, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil {
+		return PeerNode{}, fmt.Errorf("invalid peer address '%s'. %s", addr, err.Error())
+	}
+
+	port, err := strconv.ParseUint(portRaw, 10, 32)
+	if err != nil {
+		return PeerNode{}, fmt.Errorf("invalid peer port '%s'. %s", addr, err.Error())
+	}
+
+	return NewPeerNode(host, port, true, common.Address{}, false, ""), nil
+}
+
+// LoadPeersFile reads bootstrap peers from a file holding one "ip:port" per line.
+// Empty lines and lines starting with '#' are ignored.
+func LoadPeersFile(path string) ([]PeerNode, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	peers := make([]PeerNode, 0)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		peer, err := ParsePeerAddress(line)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, peer)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return peers, nil
+}
@@ -1,9 +1,11 @@
 package node
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -14,6 +16,7 @@ import (
 
 type ErrRes struct {
 	Error string `json:"error"`
+	Code  string `json:"code"`
 }
 
 type BalanceRes struct {
@@ -22,13 +25,27 @@ type BalanceRes struct {
 }
 
 type TxAddReq struct {
+	From     string          `json:"from"`
+	FromPwd  string          `json:"from_pwd"`
+	To       string          `json:"to"`
+	Gas      uint            `json:"gas"`
+	GasPrice uint            `json:"gas_price"`
+	Value    uint            `json:"value"`
+	Data     string          `json:"data"`
+	MaxFee   uint            `json:"max_fee"`
+	MaxTip   uint            `json:"max_tip"`
+	Token    string          `json:"token"`
+	Type     string          `json:"type"`
+	Payload  json.RawMessage `json:"payload"`
+	Outputs  []core.TxOutput `json:"outputs"`
+}
+
+type TokenCreateReq struct {
 	From     string `json:"from"`
 	FromPwd  string `json:"from_pwd"`
-	To       string `json:"to"`
-	Gas      uint   `json:"gas"`
-	GasPrice uint   `json:"gas_price"`
-	Value    uint   `json:"value"`
-	Data     string `json:"data"`
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+	Supply   uint   `json:"supply"`
 }
 
 type TxAddress struct {
@@ -42,12 +59,95 @@ type StatusRes struct {
 	PendingTxs  []core.SignedTx     `json:"pending_txs"`
 	NodeVersion string              `json:"node_version"`
 	Account     common.Address      `json:"account"`
+	IsLight     bool                `json:"is_light"`
+	Finalized   *FinalizedRes       `json:"finalized,omitempty"`
+}
+
+// FinalizedRes is the last irreversible block, see core.FinalityConfig
+type FinalizedRes struct {
+	Hash   core.Hash `json:"block_hash"`
+	Number uint64    `json:"block_number"`
 }
 
 type SyncRes struct {
 	Blocks []core.Block `json:"blocks"`
 }
 
+type HeadersRes struct {
+	Headers []core.BlockHeaderFS `json:"headers"`
+}
+
+type SupplyRes struct {
+	Hash   core.Hash `json:"block_hash"`
+	Number uint64    `json:"block_number"`
+	core.Supply
+}
+
+type FeesEstimateRes struct {
+	Hash   core.Hash `json:"block_hash"`
+	Number uint64    `json:"block_number"`
+	core.FeeEstimate
+}
+
+// AccountRes describes an account, locked is the value locked for it and not claimed yet
+type AccountRes struct {
+	Hash    core.Hash       `json:"block_hash"`
+	Number  uint64          `json:"block_number"`
+	Account common.Address  `json:"account"`
+	Balance uint            `json:"balance"`
+	Nonce   uint            `json:"nonce"`
+	Locked  uint            `json:"locked"`
+	Locks   []core.Lock     `json:"locks"`
+	Tokens  map[string]uint `json:"tokens"`
+}
+
+// ContractCallReq runs a contract without a tx, its state changes are discarded
+type ContractCallReq struct {
+	Contract string      `json:"contract"`
+	Caller   string      `json:"caller"`
+	Args     []core.Hash `json:"args"`
+}
+
+type ContractCallRes struct {
+	Hash core.Hash `json:"block_hash"`
+	core.CallResult
+}
+
+type MinerRes struct {
+	Enabled          bool   `json:"enabled"`
+	AllowEmptyBlocks bool   `json:"allow_empty_blocks"`
+	MinTxs           int    `json:"min_txs"`
+	MaxBlockSize     int    `json:"max_block_size"`
+	Interval         string `json:"interval"`
+	InstantSeal      bool   `json:"instant_seal"`
+}
+
+type MineBlocksReq struct {
+	Blocks int `json:"blocks"`
+}
+
+type MineBlocksRes struct {
+	Hashes []core.Hash `json:"block_hashes"`
+}
+
+type ContractStorageRes struct {
+	Hash     core.Hash      `json:"block_hash"`
+	Contract common.Address `json:"contract"`
+	Key      core.Hash      `json:"key"`
+	Value    core.Hash      `json:"value"`
+}
+
+type TokensRes struct {
+	Hash   core.Hash    `json:"block_hash"`
+	Tokens []core.Token `json:"tokens"`
+}
+
+type TokenBalancesRes struct {
+	Hash     core.Hash       `json:"block_hash"`
+	Account  common.Address  `json:"account"`
+	Balances map[string]uint `json:"balances"`
+}
+
 type AddPeerRes struct {
 	Success bool   `json:"success"`
 	Error   string `json:"error"`
@@ -70,26 +170,67 @@ func txAddHandler(w http.ResponseWriter, r *http.Request, node *Node) {
 	from := core.NewAccount(req.From)
 
 	if from.String() == common.HexToAddress("").String() {
-		writeErrRes(w, fmt.Errorf("%s is an invalid 'from' sender", from.String()))
+		writeErrRes(w, fmt.Errorf("%w: %s is an invalid 'from' sender", ErrBadRequest, from.String()))
 		return
 	}
 
 	if req.FromPwd == "" {
-		writeErrRes(w, fmt.Errorf("password to decrypt the %s account is required. 'from_pwd' is empty", from.String()))
+		writeErrRes(w, fmt.Errorf("%w: password to decrypt the %s account is required. 'from_pwd' is empty", ErrBadRequest, from.String()))
 		return
 	}
 
 	nonce := node.state.GetNextAccountNonce(from)
 	tx := core.NewTx(from, core.NewAccount(req.To), req.Gas, req.GasPrice, req.Value, nonce, req.Data)
+	if req.MaxFee > 0 {
+		tx = core.NewDynamicFeeTx(from, core.NewAccount(req.To), req.Gas, req.MaxFee, req.MaxTip, req.Value, nonce, req.Data)
+	}
 
-	signedTx, err := wallet.SignWithKeystoreAccount(tx, from, req.FromPwd, wallet.GetKeystoreDirPath(node.dataDir))
+	// Token transfers move 'value' units of the token instead of native coins
+	if req.Token != "" {
+		tx = core.NewTokenTransferTx(from, core.NewAccount(req.To), req.Token, req.Value, nonce)
+	}
 
+	// Batches pay every output at once, 'to' and 'value' are ignored
+	if len(req.Outputs) > 0 {
+		tx = core.NewMultiTransferTx(from, req.Outputs, nonce)
+	}
+
+	// Other kinds of txs are described by their type and payload, their gas depends on it.
+	// Only contract calls take the requested gas, as their gas limit.
+	if req.Type != "" {
+		tx.Type = core.TxType(req.Type)
+		tx.Payload = req.Payload
+		tx.Gas = req.Gas
+		tx.Gas = tx.RequiredGas()
+	}
+
+	// The fee fields apply to every kind of tx, the constructors above use the default gas price
+	if req.MaxFee > 0 {
+		tx.GasPrice, tx.MaxFee, tx.MaxTip = 0, req.MaxFee, req.MaxTip
+	} else if req.GasPrice > 0 {
+		tx.GasPrice = req.GasPrice
+	}
+
+	err = signAndAddPendingTx(node, tx, req.FromPwd)
 	if err != nil {
 		writeErrRes(w, err)
 		return
 	}
 
-	err = node.AddPendingTX(signedTx, node.info)
+	writeRes(w, TxAddress{Success: true})
+}
+
+// txSubmitHandler adds a tx signed outside of the node, e.g. a multisig tx
+// combined from the partial signatures of its signers
+func txSubmitHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	tx := core.SignedTx{}
+	err := readReq(r, &tx)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	err = node.AddPendingTX(tx, node.info)
 	if err != nil {
 		writeErrRes(w, err)
 		return
@@ -98,6 +239,46 @@ func txAddHandler(w http.ResponseWriter, r *http.Request, node *Node) {
 	writeRes(w, TxAddress{Success: true})
 }
 
+func tokenCreateHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	req := TokenCreateReq{}
+	err := readReq(r, &req)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	from := core.NewAccount(req.From)
+
+	if from.String() == common.HexToAddress("").String() {
+		writeErrRes(w, fmt.Errorf("%w: %s is an invalid 'from' sender", ErrBadRequest, from.String()))
+		return
+	}
+
+	nonce := node.state.GetNextAccountNonce(from)
+	tx := core.NewTokenCreateTx(from, req.Symbol, req.Decimals, req.Supply, nonce)
+
+	err = signAndAddPendingTx(node, tx, req.FromPwd)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, TxAddress{Success: true})
+}
+
+func signAndAddPendingTx(node *Node, tx core.Tx, fromPwd string) error {
+	if fromPwd == "" {
+		return fmt.Errorf("%w: password to decrypt the %s account is required. 'from_pwd' is empty", ErrBadRequest, tx.From.String())
+	}
+
+	signedTx, err := wallet.SignWithKeystoreAccount(tx, tx.From, fromPwd, wallet.GetKeystoreDirPath(node.dataDir))
+	if err != nil {
+		return err
+	}
+
+	return node.AddPendingTX(signedTx, node.info)
+}
+
 func statusHandler(w http.ResponseWriter, r *http.Request, node *Node) {
 	enableCors(&w)
 
@@ -110,20 +291,26 @@ func statusHandler(w http.ResponseWriter, r *http.Request, node *Node) {
 		Account:     core.NewAccount(node.info.Account.String()),
 	}
 
+	if number, ok := node.state.FinalizedNumber(); ok {
+		block, err := core.GetBlockByHeightOrHash(node.state, number, "", node.dataDir)
+		if err != nil {
+			writeErrRes(w, err)
+			return
+		}
+		res.Finalized = &FinalizedRes{block.Key, number}
+	}
+
 	writeRes(w, res)
 }
 
 func syncHandler(w http.ResponseWriter, r *http.Request, node *Node) {
-	reqHash := r.URL.Query().Get(endpointSyncQueryKeyFromBlock)
-
-	hash := core.Hash{}
-	err := hash.UnmarshalText([]byte(reqHash))
+	hash, limit, err := readSyncQuery(r)
 	if err != nil {
 		writeErrRes(w, err)
 		return
 	}
 
-	blocks, err := core.GetBlockAfter(hash, node.dataDir)
+	blocks, err := core.GetBlocksAfter(hash, limit, node.dataDir)
 	if err != nil {
 		writeErrRes(w, err)
 		return
@@ -132,6 +319,48 @@ func syncHandler(w http.ResponseWriter, r *http.Request, node *Node) {
 	writeRes(w, SyncRes{Blocks: blocks})
 }
 
+func headersHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	hash, limit, err := readSyncQuery(r)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	headers, err := core.GetBlockHeadersAfter(hash, limit, node.dataDir)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, HeadersRes{Headers: headers})
+}
+
+// readSyncQuery parses the block to sync from and the page size, capped to syncMaxPageSize
+func readSyncQuery(r *http.Request) (core.Hash, int, error) {
+	reqHash := r.URL.Query().Get(endpointSyncQueryKeyFromBlock)
+	reqLimit := r.URL.Query().Get(endpointSyncQueryKeyLimit)
+
+	hash := core.Hash{}
+	err := hash.UnmarshalText([]byte(reqHash))
+	if err != nil {
+		return core.Hash{}, 0, fmt.Errorf("%w: invalid block hash. %s", ErrBadRequest, err.Error())
+	}
+
+	limit := syncMaxPageSize
+	if reqLimit != "" {
+		limit, err = strconv.Atoi(reqLimit)
+		if err != nil {
+			return core.Hash{}, 0, fmt.Errorf("%w: invalid limit '%s'", ErrBadRequest, reqLimit)
+		}
+	}
+
+	if limit <= 0 || limit > syncMaxPageSize {
+		limit = syncMaxPageSize
+	}
+
+	return hash, limit, nil
+}
+
 func addPeerHandler(w http.ResponseWriter, r *http.Request, node *Node) {
 	peerIP := r.URL.Query().Get(endpointAddPeerQueryKeyIP)
 	peerPortRaw := r.URL.Query().Get(endpointAddPeerQueryKeyPort)
@@ -147,7 +376,7 @@ func addPeerHandler(w http.ResponseWriter, r *http.Request, node *Node) {
 	peer := NewPeerNode(peerIP, peerPort, false, core.NewAccount(minerRaw), true, versionRaw)
 	node.AddPeer(peer)
 
-	fmt.Println("peer '%s' was added into knownpeers\n", peer.TcpAddress())
+	componentLogger(logComponentSync).Info("peer joined", "peer", peer.TcpAddress(), "version", peer.NodeVersion)
 
 	writeRes(w, AddPeerRes{true, ""})
 }
@@ -155,7 +384,7 @@ func addPeerHandler(w http.ResponseWriter, r *http.Request, node *Node) {
 func blockByNumberOrHash(w http.ResponseWriter, r *http.Request, node *Node) {
 	enableCors(&w)
 
-	errorParamsRequired := errors.New("height or hash param is required")
+	errorParamsRequired := fmt.Errorf("%w: height or hash param is required", ErrBadRequest)
 	params := strings.Split(r.URL.Path, "/")[1:]
 	if len(params) < 2 {
 		writeErrRes(w, errorParamsRequired)
@@ -187,3 +416,245 @@ func mempoolViewer(w http.ResponseWriter, r *http.Request, txs map[string]core.S
 	enableCors(&w)
 	writeRes(w, txs)
 }
+
+func supplyHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	writeRes(w, SupplyRes{
+		Hash:   node.state.LatestBlockHash(),
+		Number: node.state.LatestBlock().Header.Number,
+		Supply: node.state.Supply(),
+	})
+}
+
+func feesEstimateHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	writeRes(w, FeesEstimateRes{
+		Hash:        node.state.LatestBlockHash(),
+		Number:      node.state.LatestBlock().Header.Number,
+		FeeEstimate: node.state.EstimateFee(),
+	})
+}
+
+func anchorHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	hashHex := strings.TrimPrefix(r.URL.Path, endpointAnchor)
+	if len(hashHex) != 2*len(core.Hash{}) {
+		writeErrRes(w, fmt.Errorf("%w: anchored hash must be %d hex characters", ErrBadRequest, 2*len(core.Hash{})))
+		return
+	}
+
+	hash := core.Hash{}
+	err := hash.UnmarshalText([]byte(hashHex))
+	if err != nil {
+		writeErrRes(w, fmt.Errorf("%w: invalid anchored hash. %s", ErrBadRequest, err.Error()))
+		return
+	}
+
+	anchor, err := node.state.GetAnchor(hash)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, anchor)
+}
+
+func contractHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	address := core.NewAccount(r.URL.Query().Get(endpointContractQueryKeyAddress))
+
+	contract, exists := node.state.Contracts[address]
+	if !exists {
+		writeErrRes(w, fmt.Errorf("%w: contract '%s'", core.ErrNotFound, address.String()))
+		return
+	}
+
+	writeRes(w, contract)
+}
+
+func contractCallHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	req := ContractCallReq{}
+	err := readReq(r, &req)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	result, err := node.state.CallContract(core.NewAccount(req.Contract), core.NewAccount(req.Caller), req.Args)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, ContractCallRes{node.state.LatestBlockHash(), result})
+}
+
+func contractStorageHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	contract := core.NewAccount(r.URL.Query().Get(endpointContractQueryKeyAddress))
+
+	key := core.Hash{}
+	err := key.UnmarshalText([]byte(r.URL.Query().Get(endpointContractQueryKeyKey)))
+	if err != nil {
+		writeErrRes(w, fmt.Errorf("%w: invalid storage key. %s", ErrBadRequest, err.Error()))
+		return
+	}
+
+	value, err := node.state.ContractStorageAt(contract, key)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, ContractStorageRes{node.state.LatestBlockHash(), contract, key, value})
+}
+
+func accountHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	account := core.NewAccount(r.URL.Query().Get(endpointAccountQueryKeyAccount))
+
+	writeRes(w, AccountRes{
+		Hash:    node.state.LatestBlockHash(),
+		Number:  node.state.LatestBlock().Header.Number,
+		Account: account,
+		Balance: node.state.Balances[account],
+		Nonce:   node.state.AccountToNonce[account],
+		Locked:  node.state.LockedBalance(account),
+		Locks:   node.state.AccountLocks(account),
+		Tokens:  node.state.AccountTokens(account),
+	})
+}
+
+func listTokensHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	tokens := make([]core.Token, 0, len(node.state.Tokens))
+	for _, token := range node.state.Tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Symbol < tokens[j].Symbol
+	})
+
+	writeRes(w, TokensRes{node.state.LatestBlockHash(), tokens})
+}
+
+func tokenBalancesHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	account := core.NewAccount(r.URL.Query().Get(endpointTokensQueryKeyAccount))
+
+	writeRes(w, TokenBalancesRes{
+		Hash:     node.state.LatestBlockHash(),
+		Account:  account,
+		Balances: node.state.AccountTokens(account),
+	})
+}
+
+func accountProofHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	account := core.NewAccount(r.URL.Query().Get(endpointProofQueryKeyAccount))
+
+	proof, err := node.state.AccountProof(account)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, proof)
+}
+
+func txProofHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	hash := core.Hash{}
+	err := hash.UnmarshalText([]byte(r.URL.Query().Get(endpointProofQueryKeyHash)))
+	if err != nil {
+		writeErrRes(w, fmt.Errorf("%w: invalid tx hash. %s", ErrBadRequest, err.Error()))
+		return
+	}
+
+	proof, err := core.GetTxProof(hash, node.dataDir)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, proof)
+}
+
+func minerStartHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	if !isLocalRequest(r) {
+		writeErrRes(w, fmt.Errorf("%w: the miner can only be started from the node host", ErrForbidden))
+		return
+	}
+
+	node.StartMining()
+	writeRes(w, newMinerRes(node.MiningPolicy()))
+}
+
+func minerStopHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	if !isLocalRequest(r) {
+		writeErrRes(w, fmt.Errorf("%w: the miner can only be stopped from the node host", ErrForbidden))
+		return
+	}
+
+	node.StopMining()
+	writeRes(w, newMinerRes(node.MiningPolicy()))
+}
+
+func minerMineHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	if !isLocalRequest(r) {
+		writeErrRes(w, fmt.Errorf("%w: blocks can only be mined on demand from the node host", ErrForbidden))
+		return
+	}
+
+	req := MineBlocksReq{}
+	err := readReq(r, &req)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	if req.Blocks < 1 || req.Blocks > maxMineBlocksPerReq {
+		writeErrRes(w, fmt.Errorf("%w: blocks must be between 1 and %d", ErrBadRequest, maxMineBlocksPerReq))
+		return
+	}
+
+	hashes, err := node.MineEmptyBlocks(r.Context(), req.Blocks)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, MineBlocksRes{hashes})
+}
+
+func newMinerRes(policy MiningPolicy) MinerRes {
+	return MinerRes{
+		Enabled:          policy.Enabled,
+		AllowEmptyBlocks: policy.AllowEmptyBlocks,
+		MinTxs:           policy.MinTxs,
+		MaxBlockSize:     policy.MaxBlockSize,
+		Interval:         policy.Interval.String(),
+		InstantSeal:      policy.InstantSeal,
+	}
+}
+
+// isLocalRequest reports whether the request comes from the node host, admin endpoints only serve those
+func isLocalRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -2,31 +2,47 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/irononet/nemos/core"
 )
 
+// peerClient sends the requests to the peers
+var peerClient = &http.Client{Timeout: peerRequestTimeout}
+
 func (n *Node) sync(ctx context.Context) error {
-	n.doSync()
+	n.doSync(ctx)
 
 	ticker := time.NewTicker(45 * time.Second)
 
 	for {
 		select {
 		case <-ticker.C:
-			n.doSync()
+			n.doSync(ctx)
 		case <-ctx.Done():
 			ticker.Stop()
+			return nil
 		}
 	}
 }
 
-func (n *Node) doSync() {
+// doSync catches up with the known peers. It gives up between requests once ctx is done.
+func (n *Node) doSync(ctx context.Context) {
+	log := componentLogger(logComponentSync)
+	peers := make(map[string]PeerNode)
+	statuses := make(map[string]StatusRes)
+
 	for _, peer := range n.knownPeers {
+		if ctx.Err() != nil {
+			return
+		}
+
 		if n.info.IP == peer.IP && n.info.Port == peer.Port {
 			continue
 		}
@@ -35,87 +51,321 @@ func (n *Node) doSync() {
 			continue
 		}
 
-		fmt.Printf("searching for new peers and their blocks and peers: '%s'\n", peer.TcpAddress())
+		log.Debug("querying peer status", "peer", peer.TcpAddress())
 
-		status, err := queryPeerStatus(peer)
+		status, err := queryPeerStatus(ctx, peer)
 		if err != nil {
-			fmt.Printf("error: %s\n", err)
-			fmt.Printf("peer '%s' was removed from knownpeers\n", peer.TcpAddress())
-
-			n.RemovePeer(peer)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn("couldn't query peer status", "peer", peer.TcpAddress(), "err", err)
+
+			if n.markPeerFailed(peer) {
+				log.Info("removed unreachable peer", "peer", peer.TcpAddress())
+			}
 			continue
 		}
 
-		err = n.joinKnownPeers(peer)
+		n.markPeerSeen(peer)
+
+		err = n.joinKnownPeers(ctx, peer)
 		if err != nil {
-			fmt.Errorf("error: %s\n", err)
+			log.Warn("couldn't join peer", "peer", peer.TcpAddress(), "err", err)
 			continue
 		}
 
-		err = n.syncBlocks(peer, status)
+		peers[peer.TcpAddress()] = peer
+		statuses[peer.TcpAddress()] = status
+	}
+
+	var err error
+	if n.isLight {
+		err = n.syncLightHeaders(ctx, peers, statuses)
+	} else {
+		err = n.syncBlocks(ctx, peers, statuses)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Error("sync failed", "err", err)
+	}
+	observeSyncLag(n.localHeight(), statuses)
+
+	for addr, status := range statuses {
+		err = n.syncKnownPeers(status)
 		if err != nil {
-			fmt.Printf("error: %s\n", err)
+			log.Warn("couldn't sync known peers", "peer", addr, "err", err)
 			continue
 		}
 
-		err = n.syncKnownPeers(status)
-		if err != nil {
-			fmt.Printf("error: %s\n", err)
+		if n.isLight {
 			continue
 		}
 
-		err = n.syncPendingTXs(peer, status.PendingTxs)
+		err = n.syncPendingTXs(peers[addr], status.PendingTxs)
 		if err != nil {
-			fmt.Printf("error: %s\n", err)
+			log.Warn("couldn't sync pending txs", "peer", addr, "err", err)
 			continue
 		}
 	}
-}
 
-func (n *Node) syncBlocks(peer PeerNode, status StatusRes) error {
-	localBlockNumber := n.state.LatestBlock().Header.Number
+	err = n.savePeers()
+	if err != nil {
+		log.Error("couldn't save peers", "err", err)
+	}
+	peersCount.Set(float64(len(n.knownPeers)))
+}
 
-	if status.Hash.IsEmpty() {
-		return nil
+// localHeight of the blocks or, for light nodes, of the headers
+func (n *Node) localHeight() uint64 {
+	if n.isLight {
+		latest, _ := n.headers.Latest()
+		return latest.Value.Number
 	}
+	return n.state.LatestBlock().Header.Number
+}
 
-	// If the peer has less blocks than us, ignore it
-	if status.Number < localBlockNumber {
-		return nil
+// syncBlocks downloads the missing blocks headers first: the header chain of the
+// highest peer is fetched and validated in batches, then the block bodies are
+// downloaded in pages from all the peers having them.
+func (n *Node) syncBlocks(ctx context.Context, peers map[string]PeerNode, statuses map[string]StatusRes) error {
+	bestAddr := ""
+	for addr, status := range statuses {
+		// Light peers can't serve blocks
+		if status.Hash.IsEmpty() || status.IsLight {
+			continue
+		}
+		if bestAddr == "" || status.Number > statuses[bestAddr].Number {
+			bestAddr = addr
+		}
 	}
 
-	// If it's the genesis block and we already synced it, ignore it
-	if status.Number == 0 && !n.state.LatestBlockHash().IsEmpty() {
+	if bestAddr == "" {
 		return nil
 	}
+	best := statuses[bestAddr]
 
-	// display found 1 new block if we sync the genesis block 0
-	newBlocksCount := status.Number - localBlockNumber
-	if localBlockNumber == 0 && status.Number == 0 {
-		newBlocksCount = 1
+	err := n.syncHeaders(ctx, peers[bestAddr], best)
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-	fmt.Printf("found %d new blocks from peer %s\n", newBlocksCount, peer.TcpAddress())
-	blocks, err := fetchBlocksFromPeer(peer, n.state.LatestBlockHash())
 	if err != nil {
+		// Headers stamped ahead of the clock may become valid later, the peer isn't at fault
+		if !errors.Is(err, core.ErrBlockFromFuture) && n.markPeerFailed(peers[bestAddr]) {
+			componentLogger(logComponentSync).Info("removed failing peer", "peer", bestAddr)
+		}
 		return err
 	}
 
-	for _, block := range blocks {
-		err = n.addBlock(block)
+	if len(n.syncCheckpoint.Headers) == 0 {
+		return nil
+	}
+
+	componentLogger(logComponentSync).Info("found new blocks", "blocks", len(n.syncCheckpoint.Headers), "peers", len(peers), "best_peer", bestAddr, "best_height", best.Number)
+
+	return n.syncBodies(ctx, peers, statuses)
+}
+
+func (n *Node) syncHeaders(ctx context.Context, peer PeerNode, status StatusRes) error {
+	pending := len(n.syncCheckpoint.Headers)
+	n.dropStaleSyncHeaders()
+	if len(n.syncCheckpoint.Headers) < pending {
+		err := n.rewriteSyncCheckpoint()
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		parent, hasParent := n.syncTip()
+
+		// Nothing left to fetch once we reached the peer's height
+		if hasParent && status.Number <= parent.Value.Number {
+			return nil
+		}
+
+		headers, err := fetchHeadersFromPeer(ctx, peer, parent.Key, syncHeadersBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(headers) == 0 {
+			return nil
+		}
+
+		// Seals are checked against the validators announced by the headers synced so far
+		validators := core.ValidatorsAfter(n.state.Validators, n.syncCheckpoint.Headers)
+		err = core.ValidateHeaderChain(headers, parent, hasParent, n.state.Engine(), validators)
+		if err != nil {
+			return fmt.Errorf("invalid headers from peer '%s'. %w", peer.TcpAddress(), err)
+		}
+
+		err = n.appendSyncHeaders(headers)
 		if err != nil {
 			return err
 		}
+	}
+}
 
-		n.newSyncedBlocks <- block
+// syncTip returns the header the next headers batch has to extend
+func (n *Node) syncTip() (core.BlockHeaderFS, bool) {
+	headers := n.syncCheckpoint.Headers
+	if len(headers) > 0 {
+		return headers[len(headers)-1], true
 	}
 
-	return nil
+	hash := n.state.LatestBlockHash()
+	return core.BlockHeaderFS{Key: hash, Value: n.state.LatestBlock().Header}, !hash.IsEmpty()
+}
+
+// syncBodies downloads the bodies of the validated headers and adds them to the
+// chain in order. Pages are spread over the peers and downloaded in parallel.
+func (n *Node) syncBodies(ctx context.Context, peers map[string]PeerNode, statuses map[string]StatusRes) error {
+	for len(n.syncCheckpoint.Headers) > 0 {
+		// The headers left stay in the checkpoint for the next run
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		pages := make([][]core.BlockHeaderFS, 0, syncMaxParallelDownloads)
+		headers := n.syncCheckpoint.Headers
+		for len(headers) > 0 && len(pages) < syncMaxParallelDownloads {
+			size := syncBlocksPageSize
+			if len(headers) < size {
+				size = len(headers)
+			}
+			pages = append(pages, headers[:size])
+			headers = headers[size:]
+		}
+
+		results := make([][]core.Block, len(pages))
+		errs := make([]error, len(pages))
+		failed := make([][]PeerNode, len(pages))
+
+		var wg sync.WaitGroup
+		for i, page := range pages {
+			candidates := syncPeerCandidates(peers, statuses, page[len(page)-1].Value.Number)
+			if len(candidates) == 0 {
+				errs[i] = fmt.Errorf("no peer has block '%d'", page[len(page)-1].Value.Number)
+				continue
+			}
+
+			parent := n.state.LatestBlockHash()
+			if i > 0 {
+				prevPage := pages[i-1]
+				parent = prevPage[len(prevPage)-1].Key
+			}
+
+			wg.Add(1)
+			go func(i int, candidates []PeerNode, parent core.Hash, page []core.BlockHeaderFS) {
+				defer wg.Done()
+
+				// Each page starts with a different peer and falls back on the others
+				for attempt := range candidates {
+					peer := candidates[(i+attempt)%len(candidates)]
+					results[i], errs[i] = fetchPageFromPeer(ctx, peer, parent, page)
+					if errs[i] == nil || ctx.Err() != nil {
+						return
+					}
+					failed[i] = append(failed[i], peer)
+				}
+			}(i, candidates, parent, page)
+		}
+		wg.Wait()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Recorded once the downloads are over, the peer stats aren't safe for concurrent use
+		for _, pagePeers := range failed {
+			for _, peer := range pagePeers {
+				if n.markPeerFailed(peer) {
+					componentLogger(logComponentSync).Info("removed failing peer", "peer", peer.TcpAddress())
+				}
+			}
+		}
+
+		for i := range pages {
+			if errs[i] != nil {
+				return errs[i]
+			}
+
+			for _, block := range results[i] {
+				err := n.addBlock(block)
+				if errors.Is(err, core.ErrBlockFromFuture) {
+					// Keep the headers, the block is retried on the next sync
+					return fmt.Errorf("deferring block '%d'. %w", block.Header.Number, err)
+				}
+				if err != nil {
+					// The peer lied about the headers, start over next time
+					_ = n.resetSyncCheckpoint()
+					return err
+				}
+
+				n.newSyncedBlocks <- block
+			}
+
+			// The imported headers stay in the file until the checkpoint is done,
+			// loading it skips the ones already in the chain
+			n.syncCheckpoint.Headers = n.syncCheckpoint.Headers[len(pages[i]):]
+		}
+	}
+
+	return n.resetSyncCheckpoint()
+}
+
+// syncPeerCandidates lists the full peers having at least the given height in random
+// order, so the pages aren't always requested from the same peers
+func syncPeerCandidates(peers map[string]PeerNode, statuses map[string]StatusRes, height uint64) []PeerNode {
+	candidates := make([]PeerNode, 0, len(peers))
+	for addr, status := range statuses {
+		if !status.Hash.IsEmpty() && !status.IsLight && status.Number >= height {
+			candidates = append(candidates, peers[addr])
+		}
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	return candidates
+}
+
+// fetchPageFromPeer downloads the bodies of the headers page, parent being
+// the hash of the block preceding the page
+func fetchPageFromPeer(ctx context.Context, peer PeerNode, parent core.Hash, page []core.BlockHeaderFS) ([]core.Block, error) {
+	blocks, err := fetchBlocksFromPeer(ctx, peer, parent, len(page))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blocks) != len(page) {
+		return nil, fmt.Errorf("peer '%s' returned %d blocks, expected %d", peer.TcpAddress(), len(blocks), len(page))
+	}
+
+	for i, block := range blocks {
+		hash, err := block.Hash()
+		if err != nil {
+			return nil, err
+		}
+
+		if hash != page[i].Key {
+			return nil, fmt.Errorf("peer '%s' returned block '%x' not matching header '%x'", peer.TcpAddress(), hash, page[i].Key)
+		}
+	}
+
+	return blocks, nil
 }
 
 func (n *Node) syncKnownPeers(status StatusRes) error {
 	for _, statusPeer := range status.KnownPeers {
 		if !n.IsKnwonPeer(statusPeer) {
-			fmt.Printf("found new peer %s\n", statusPeer.TcpAddress())
+			componentLogger(logComponentSync).Info("found new peer", "peer", statusPeer.TcpAddress())
 			n.AddPeer(statusPeer)
 		}
 	}
@@ -132,7 +382,7 @@ func (n *Node) syncPendingTXs(peer PeerNode, txs []core.SignedTx) error {
 	return nil
 }
 
-func (n *Node) joinKnownPeers(peer PeerNode) error {
+func (n *Node) joinKnownPeers(ctx context.Context, peer PeerNode) error {
 	if peer.connected {
 		return nil
 	}
@@ -152,7 +402,7 @@ func (n *Node) joinKnownPeers(peer PeerNode) error {
 		url.QueryEscape(n.info.NodeVersion),
 	)
 
-	res, err := http.Get(p_url)
+	res, err := getFromPeer(ctx, p_url)
 	if err != nil {
 		return err
 	}
@@ -176,9 +426,9 @@ func (n *Node) joinKnownPeers(peer PeerNode) error {
 	return nil
 }
 
-func queryPeerStatus(peer PeerNode) (StatusRes, error) {
+func queryPeerStatus(ctx context.Context, peer PeerNode) (StatusRes, error) {
 	url := fmt.Sprintf("%s://%s%s", peer.ApiProtocol(), peer.TcpAddress(), endpointStatus)
-	res, err := http.Get(url)
+	res, err := getFromPeer(ctx, url)
 	if err != nil {
 		return StatusRes{}, err
 	}
@@ -192,19 +442,21 @@ func queryPeerStatus(peer PeerNode) (StatusRes, error) {
 	return statusRes, nil
 }
 
-func fetchBlocksFromPeer(peer PeerNode, fromBlock core.Hash) ([]core.Block, error) {
-	fmt.Printf("importing blocks from peer %s...\n", peer.TcpAddress())
+func fetchBlocksFromPeer(ctx context.Context, peer PeerNode, fromBlock core.Hash, limit int) ([]core.Block, error) {
+	componentLogger(logComponentSync).Debug("importing blocks", "peer", peer.TcpAddress(), "from", fromBlock.Hex(), "limit", limit)
 
 	url := fmt.Sprintf(
-		"%s://%s%s?%s=%s",
+		"%s://%s%s?%s=%s&%s=%d",
 		peer.ApiProtocol(),
 		peer.TcpAddress(),
 		endpointSync,
 		endpointSyncQueryKeyFromBlock,
 		fromBlock.Hex(),
+		endpointSyncQueryKeyLimit,
+		limit,
 	)
 
-	res, err := http.Get(url)
+	res, err := getFromPeer(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -217,3 +469,40 @@ func fetchBlocksFromPeer(peer PeerNode, fromBlock core.Hash) ([]core.Block, erro
 
 	return syncRes.Blocks, nil
 }
+
+func fetchHeadersFromPeer(ctx context.Context, peer PeerNode, fromBlock core.Hash, limit int) ([]core.BlockHeaderFS, error) {
+	componentLogger(logComponentSync).Debug("importing headers", "peer", peer.TcpAddress(), "from", fromBlock.Hex(), "limit", limit)
+
+	url := fmt.Sprintf(
+		"%s://%s%s?%s=%s&%s=%d",
+		peer.ApiProtocol(),
+		peer.TcpAddress(),
+		endpointHeaders,
+		endpointSyncQueryKeyFromBlock,
+		fromBlock.Hex(),
+		endpointSyncQueryKeyLimit,
+		limit,
+	)
+
+	res, err := getFromPeer(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	headersRes := HeadersRes{}
+	err = readRes(res, &headersRes)
+	if err != nil {
+		return nil, err
+	}
+
+	return headersRes.Headers, nil
+}
+
+// getFromPeer sends a GET request to a peer, cancelled with ctx or after peerRequestTimeout
+func getFromPeer(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.Do(req)
+}
@@ -0,0 +1,160 @@
+package node
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/irononet/nemos/core"
+)
+
+const syncCheckpointFileName = "sync_headers.db"
+
+// syncCheckpoint holds the validated headers whose bodies weren't imported yet,
+// so an interrupted sync resumes where it left off instead of refetching them.
+// The headers are appended to the file as they are validated, the ones already
+// in the chain are skipped when loading it and the file is emptied once they all are.
+type syncCheckpoint struct {
+	Headers []core.BlockHeaderFS
+}
+
+func getSyncCheckpointFilePath(dataDir string) string {
+	return filepath.Join(dataDir, syncCheckpointFileName)
+}
+
+func (n *Node) loadSyncCheckpoint() error {
+	f, err := os.Open(getSyncCheckpointFilePath(n.dataDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	headers := make([]core.BlockHeaderFS, 0)
+	isCorrupted := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var header core.BlockHeaderFS
+		err = json.Unmarshal(scanner.Bytes(), &header)
+		if err != nil {
+			componentLogger(logComponentSync).Warn("ignoring unreadable sync checkpoint headers", "after", len(headers), "err", err)
+			isCorrupted = true
+			break
+		}
+		headers = append(headers, header)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	n.syncCheckpoint.Headers = headers
+	n.dropStaleSyncHeaders()
+
+	// Rewritten so the next headers aren't appended after stale or unreadable ones
+	if isCorrupted || len(n.syncCheckpoint.Headers) < len(headers) {
+		err = n.rewriteSyncCheckpoint()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(n.syncCheckpoint.Headers) > 0 {
+		componentLogger(logComponentSync).Info("resuming sync", "blocks", len(n.syncCheckpoint.Headers))
+	}
+
+	return nil
+}
+
+// appendSyncHeaders adds the validated headers to the checkpoint
+func (n *Node) appendSyncHeaders(headers []core.BlockHeaderFS) error {
+	n.syncCheckpoint.Headers = append(n.syncCheckpoint.Headers, headers...)
+
+	f, err := os.OpenFile(getSyncCheckpointFilePath(n.dataDir), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writeSyncHeaders(f, headers)
+}
+
+// rewriteSyncCheckpoint replaces the file with the headers left to import
+func (n *Node) rewriteSyncCheckpoint() error {
+	if len(n.syncCheckpoint.Headers) == 0 {
+		return n.resetSyncCheckpoint()
+	}
+
+	tmpPath := getSyncCheckpointFilePath(n.dataDir) + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = writeSyncHeaders(f, n.syncCheckpoint.Headers)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, getSyncCheckpointFilePath(n.dataDir))
+}
+
+// resetSyncCheckpoint forgets the headers, once imported or when they can't be
+func (n *Node) resetSyncCheckpoint() error {
+	n.syncCheckpoint.Headers = nil
+
+	err := os.Remove(getSyncCheckpointFilePath(n.dataDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func writeSyncHeaders(f *os.File, headers []core.BlockHeaderFS) error {
+	w := bufio.NewWriter(f)
+	for _, header := range headers {
+		headerJson, err := json.Marshal(header)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(append(headerJson, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// dropStaleSyncHeaders forgets the headers already in the chain and the whole
+// checkpoint if it no longer extends the local chain
+func (n *Node) dropStaleSyncHeaders() {
+	headers := n.syncCheckpoint.Headers
+	latestHash := n.state.LatestBlockHash()
+
+	if latestHash.IsEmpty() {
+		if len(headers) > 0 && headers[0].Value.Number != 0 {
+			n.syncCheckpoint.Headers = nil
+		}
+		return
+	}
+
+	latestNumber := n.state.LatestBlock().Header.Number
+	for len(headers) > 0 && headers[0].Value.Number <= latestNumber {
+		headers = headers[1:]
+	}
+
+	if len(headers) > 0 && headers[0].Value.Parent != latestHash {
+		headers = nil
+	}
+
+	n.syncCheckpoint.Headers = headers
+}
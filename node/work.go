@@ -0,0 +1,182 @@
+package node
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/irononet/nemos/core"
+)
+
+const endpointMinerWork = "/miner/work"
+const endpointMinerWorkQueryKeyMiner = "miner"
+const endpointMinerSubmit = "/miner/submit"
+
+// maxPendingWork bounds the block templates kept for external miners
+const maxPendingWork = 16
+
+// WorkRes is a block template for external miners. A solution is a Nonce making the sha256
+// of the JSON encoded Header start with exactly Difficulty zero bytes.
+type WorkRes struct {
+	WorkID     core.Hash        `json:"work_id"`
+	Header     core.BlockHeader `json:"header"`
+	Difficulty uint             `json:"difficulty"`
+}
+
+type SubmitWorkReq struct {
+	WorkID core.Hash `json:"work_id"`
+	Nonce  uint32    `json:"nonce"`
+}
+
+type SubmitWorkRes struct {
+	Hash   core.Hash `json:"block_hash"`
+	Number uint64    `json:"block_number"`
+}
+
+// pendingWork is a block template along with the mempool version and policy it was built from
+type pendingWork struct {
+	block          core.Block
+	mempoolVersion uint64
+	policy         MiningPolicy
+}
+
+// GetWork hands out the template of the next block, rewarding miner.
+// Templates on top of an older tip are dropped as they can't be accepted anymore.
+// A template is only rebuilt once the tip, the mempool or the policy changes.
+func (n *Node) GetWork(miner common.Address) (WorkRes, error) {
+	engine, isPoW := n.state.Engine().(*core.PoWEngine)
+	if !isPoW {
+		return WorkRes{}, fmt.Errorf("%w: external mining requires proof of work", ErrBadRequest)
+	}
+
+	// External miners follow the policy even while the node doesn't mine itself
+	policy := n.MiningPolicy()
+	policy.Enabled = true
+
+	txs, mempoolVersion := n.pendingTxsState()
+	if !policy.shouldMine(len(txs)) {
+		return WorkRes{}, fmt.Errorf("%w: not enough pending txs to mine a block", core.ErrNotFound)
+	}
+
+	if workID, block, ok := n.cachedWork(miner, mempoolVersion, policy); ok {
+		return WorkRes{workID, block.Header, engine.Difficulty()}, nil
+	}
+
+	pb, err := n.newPendingBlock(miner, txs, policy)
+	if err != nil {
+		return WorkRes{}, err
+	}
+	if !policy.shouldMine(len(pb.txs)) {
+		return WorkRes{}, fmt.Errorf("%w: not enough pending txs to mine a block", core.ErrNotFound)
+	}
+
+	block := pb.Block()
+	workID, err := block.Header.Hash()
+	if err != nil {
+		return WorkRes{}, err
+	}
+
+	n.workMu.Lock()
+	defer n.workMu.Unlock()
+
+	for id, w := range n.work {
+		if w.block.Header.Parent != block.Header.Parent || len(n.work) >= maxPendingWork {
+			delete(n.work, id)
+		}
+	}
+	n.work[workID] = pendingWork{block, mempoolVersion, policy}
+
+	return WorkRes{workID, block.Header, engine.Difficulty()}, nil
+}
+
+// cachedWork returns the template already built for miner on top of the tip out of the same mempool
+func (n *Node) cachedWork(miner common.Address, mempoolVersion uint64, policy MiningPolicy) (core.Hash, core.Block, bool) {
+	n.stateMu.Lock()
+	tip := n.state.LatestBlockHash()
+	n.stateMu.Unlock()
+
+	n.workMu.Lock()
+	defer n.workMu.Unlock()
+
+	for id, w := range n.work {
+		if w.block.Header.Parent == tip && w.block.Header.Miner == miner && w.mempoolVersion == mempoolVersion && w.policy == policy {
+			return id, w.block, true
+		}
+	}
+	return core.Hash{}, core.Block{}, false
+}
+
+// SubmitWork seals the template with the nonce found by an external miner and adds the block.
+// Peers fetch it on their next sync.
+func (n *Node) SubmitWork(workID core.Hash, nonce uint32) (core.Block, error) {
+	n.workMu.Lock()
+	w, ok := n.work[workID]
+	n.workMu.Unlock()
+
+	if !ok {
+		return core.Block{}, fmt.Errorf("%w: unknown or stale work '%s'", core.ErrNotFound, workID.Hex())
+	}
+
+	block := w.block
+	block.Header.Nonce = nonce
+	err := n.addBlock(block)
+	if err != nil {
+		return core.Block{}, err
+	}
+
+	n.workMu.Lock()
+	n.work = make(map[core.Hash]pendingWork)
+	n.workMu.Unlock()
+
+	n.removeMinedPendingTxs(block)
+
+	// Cancels the block the node is mining on the same height
+	n.newSyncedBlocks <- block
+
+	return block, nil
+}
+
+func minerWorkHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	enableCors(&w)
+
+	miner := node.info.Account
+	if value := r.URL.Query().Get(endpointMinerWorkQueryKeyMiner); value != "" {
+		if !common.IsHexAddress(value) {
+			writeErrRes(w, fmt.Errorf("%w: invalid miner account '%s'", ErrBadRequest, value))
+			return
+		}
+		miner = common.HexToAddress(value)
+	}
+
+	work, err := node.GetWork(miner)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, work)
+}
+
+func minerSubmitHandler(w http.ResponseWriter, r *http.Request, node *Node) {
+	req := SubmitWorkReq{}
+	err := readReq(r, &req)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	block, err := node.SubmitWork(req.WorkID, req.Nonce)
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	hash, err := block.Hash()
+	if err != nil {
+		writeErrRes(w, err)
+		return
+	}
+
+	writeRes(w, SubmitWorkRes{hash, block.Header.Number})
+}
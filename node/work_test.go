@@ -0,0 +1,70 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/irononet/nemos/core"
+)
+
+func TestGetWorkReusesTemplate(t *testing.T) {
+	dataDir := t.TempDir()
+
+	const difficulty = 1
+	state, err := core.NewStateFromDisk(dataDir, difficulty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+
+	miner := core.NewAccount("0x01")
+	n := New(dataDir, "127.0.0.1", 8085, miner, nil, "", difficulty)
+	n.state = state
+	pendingState := state.Copy()
+	n.pendingState = &pendingState
+
+	n.ChangeMiningPolicy(MiningPolicy{AllowEmptyBlocks: true})
+
+	work, err := n.GetWork(miner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A rebuilt template would be stamped with a later time
+	time.Sleep(1100 * time.Millisecond)
+
+	cached, err := n.GetWork(miner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.WorkID != work.WorkID {
+		t.Errorf("expected the template %s to be reused, got %s", work.WorkID.Hex(), cached.WorkID.Hex())
+	}
+
+	other, err := n.GetWork(core.NewAccount("0x02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.WorkID == work.WorkID {
+		t.Errorf("expected a template rewarding the other miner")
+	}
+
+	_, err = n.MineEmptyBlocks(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := n.GetWork(miner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Header.Parent != n.state.LatestBlockHash() {
+		t.Errorf("expected the template to be rebuilt on top of the new tip")
+	}
+
+	_, err = n.SubmitWork(work.WorkID, 0)
+	if err == nil {
+		t.Errorf("expected the template on top of the old tip to be dropped")
+	}
+}
@@ -31,28 +31,62 @@ func NewKeystoreAccount(dataDir, password string) (common.Address, error) {
 	return acc.Address, nil
 }
 
+// ImportKeystoreAccount stores the key in the data dir keystore. The key is encrypted with
+// the light scrypt parameters, meant for throw-away accounts only.
+func ImportKeystoreAccount(dataDir string, key *ecdsa.PrivateKey, password string) (common.Address, error) {
+	ks := keystore.NewKeyStore(GetKeystoreDirPath(dataDir), keystore.LightScryptN, keystore.LightScryptP)
+	acc, err := ks.ImportECDSA(key, password)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return acc.Address, nil
+}
+
 func SignWithKeystoreAccount(tx core.Tx, acc common.Address, pwd, keystoreDir string) (core.SignedTx, error) {
-	ks := keystore.NewKeyStore(keystoreDir, keystore.StandardScryptN, keystore.StandardScryptP)
-	ksAccount, err := ks.Find(accounts.Account{Address: acc})
+	key, err := decryptKeystoreAccount(acc, pwd, keystoreDir)
 	if err != nil {
 		return core.SignedTx{}, err
 	}
 
-	ksAccountJson, err := ioutil.ReadFile(ksAccount.URL.Path)
+	signedTx, err := SignTx(tx, key.PrivateKey)
 	if err != nil {
 		return core.SignedTx{}, err
 	}
+	return signedTx, nil
+}
 
-	key, err := keystore.DecryptKey(ksAccountJson, pwd)
+// SignPartialWithKeystoreAccount signs the tx of a multisig account as one of its signers
+func SignPartialWithKeystoreAccount(tx core.Tx, acc common.Address, pwd, keystoreDir string) ([]byte, error) {
+	key, err := decryptKeystoreAccount(acc, pwd, keystoreDir)
 	if err != nil {
-		return core.SignedTx{}, err
+		return nil, err
 	}
 
-	signedTx, err := SignTx(tx, key.PrivateKey)
+	return SignPartial(tx, key.PrivateKey)
+}
+
+// DecryptPrivateKey returns the private key of the keystore account
+func DecryptPrivateKey(acc common.Address, pwd, keystoreDir string) (*ecdsa.PrivateKey, error) {
+	key, err := decryptKeystoreAccount(acc, pwd, keystoreDir)
 	if err != nil {
-		return core.SignedTx{}, err
+		return nil, err
 	}
-	return signedTx, nil
+	return key.PrivateKey, nil
+}
+
+func decryptKeystoreAccount(acc common.Address, pwd, keystoreDir string) (*keystore.Key, error) {
+	ks := keystore.NewKeyStore(keystoreDir, keystore.StandardScryptN, keystore.StandardScryptP)
+	ksAccount, err := ks.Find(accounts.Account{Address: acc})
+	if err != nil {
+		return nil, err
+	}
+
+	ksAccountJson, err := ioutil.ReadFile(ksAccount.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return keystore.DecryptKey(ksAccountJson, pwd)
 }
 
 func SignTx(tx core.Tx, privKey *ecdsa.PrivateKey) (core.SignedTx, error) {
@@ -69,6 +103,50 @@ func SignTx(tx core.Tx, privKey *ecdsa.PrivateKey) (core.SignedTx, error) {
 	return core.NewSignedTx(tx, sig), nil
 }
 
+// SignPartial returns the signature of one of the signers of a multisig tx.
+// The signers sign offline and the signatures are joined with CombineSignatures.
+func SignPartial(tx core.Tx, privKey *ecdsa.PrivateKey) ([]byte, error) {
+	rawTx, err := tx.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return Sign(rawTx, privKey)
+}
+
+// CombineSignatures joins the partial signatures of a multisig tx, skipping duplicates
+// and signatures not matching the tx
+func CombineSignatures(tx core.Tx, sigs ...[]byte) (core.SignedTx, error) {
+	rawTx, err := tx.Encode()
+	if err != nil {
+		return core.SignedTx{}, err
+	}
+
+	signers := make(map[common.Address]bool)
+	combined := make([][]byte, 0, len(sigs))
+
+	for _, sig := range sigs {
+		pubKey, err := Verify(rawTx, sig)
+		if err != nil {
+			continue
+		}
+
+		signer := crypto.PubkeyToAddress(*pubKey)
+		if signers[signer] {
+			continue
+		}
+
+		signers[signer] = true
+		combined = append(combined, sig)
+	}
+
+	if len(combined) == 0 {
+		return core.SignedTx{}, fmt.Errorf("no valid signature to combine")
+	}
+
+	return core.NewMultisigTx(tx, combined), nil
+}
+
 func Sign(msg []byte, privKey *ecdsa.PrivateKey) (sig []byte, err error) {
 	msgHash := sha256.Sum256(msg)
 	return crypto.Sign(msgHash[:], privKey)
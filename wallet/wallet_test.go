@@ -171,3 +171,37 @@ func TestSignForgedTxWithKeystoreAccount(t *testing.T){
 	}
 }
 
+
+func TestCombineSignatures(t *testing.T){
+	keys := make([]*ecdsa.PrivateKey, 3) 
+	signers := make([]common.Address, 3) 
+	for i := range keys{
+		key, err := NewRandomKey() 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		keys[i] = key.PrivateKey 
+		signers[i] = key.Address 
+	}
+
+	multisig := core.NewMultisigAccount(signers, 2) 
+	tx := core.NewBaseTx(multisig.Address(), signers[0], 10, 1, "") 
+
+	sigs := make([][]byte, 0) 
+	for _, key := range keys[:2]{
+		sig, err := SignPartial(tx, key) 
+		if err != nil{
+			t.Fatal(err) 
+		}
+		sigs = append(sigs, sig, sig) 
+	}
+
+	signedTx, err := CombineSignatures(tx, append(sigs, []byte("not a signature"))...) 
+	if err != nil{
+		t.Fatal(err) 
+	}
+
+	if len(signedTx.Sigs) != 2{
+		t.Fatalf("expected the 2 distinct signatures to be combined, got %d", len(signedTx.Sigs))
+	}
+}